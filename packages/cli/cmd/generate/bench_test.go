@@ -0,0 +1,126 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// syntheticSchemaFiles is the size of the synthetic repo benchmarks run on.
+const syntheticSchemaFiles = 1000
+
+// writeSyntheticRepo creates n schema files under dir. Every tenth file
+// imports a shared subdocument, so bundling follows local imports too.
+func writeSyntheticRepo(b *testing.B, dir string, n int) []string {
+	b.Helper()
+	shared := `import { defineSubDocument, fields } from "@monkko/orm/schemas";
+
+export const Address = defineSubDocument({
+  street: fields.string({ required: true }),
+  city: fields.string(),
+});
+`
+	if err := os.WriteFile(filepath.Join(dir, "shared.ts"), []byte(shared), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	files := make([]string, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Model%04d", i)
+		imports := `import { defineSchema, fields } from "@monkko/orm/schemas";`
+		extra := ""
+		if i%10 == 0 {
+			imports += "\nimport { Address } from \"./shared\";"
+			extra = "\n    address: Address(),"
+		}
+		source := fmt.Sprintf(`%s
+
+/** Synthetic schema %d */
+export const %s = defineSchema({
+  name: "%s",
+  db: "bench",
+  collection: "%s",
+  fields: {
+    title: fields.string({ required: true, minLength: 1, maxLength: 200 }),
+    status: fields.string({ enum: ["draft", "published"], default: "draft" }),
+    views: fields.number({ min: 0, default: 0 }),
+    published: fields.boolean(),
+    publishedAt: fields.date(),
+    ownerId: fields.objectId(),
+    tags: fields.array(fields.string()),%s
+  },
+  options: { timestamps: true },
+});
+`, imports, i, name, name, plural(camel(name)), extra)
+		files[i] = filepath.Join(dir, name+".monkko.ts")
+		if err := os.WriteFile(files[i], []byte(source), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return files
+}
+
+func benchmarkParse(b *testing.B, jobs int, cached bool) {
+	dir := b.TempDir()
+	files := writeSyntheticRepo(b, dir, syntheticSchemaFiles)
+	var cache *Cache
+	if cached {
+		cache = OpenCache(filepath.Join(dir, cacheDir), false, false)
+		ParseSchemaFiles(files, cache, jobs, false)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		schemas, diags := ParseSchemaFiles(files, cache, jobs, false)
+		if diags.HasErrors() || len(schemas) != syntheticSchemaFiles {
+			b.Fatalf("parsed %d schema(s) with %d error(s)", len(schemas), diags.Count(SeverityError))
+		}
+	}
+}
+
+func BenchmarkParseSchemaFiles(b *testing.B) {
+	b.Run("serial", func(b *testing.B) { benchmarkParse(b, 1, false) })
+	b.Run("parallel", func(b *testing.B) { benchmarkParse(b, runtime.NumCPU(), false) })
+	b.Run("cached", func(b *testing.B) { benchmarkParse(b, runtime.NumCPU(), true) })
+}
+
+func BenchmarkGenerateOutputs(b *testing.B) {
+	dir := b.TempDir()
+	files := writeSyntheticRepo(b, dir, syntheticSchemaFiles)
+	schemas, diags := ParseSchemaFiles(files, nil, runtime.NumCPU(), false)
+	if diags.HasErrors() {
+		b.Fatalf("parsing failed with %d error(s)", diags.Count(SeverityError))
+	}
+	templates, diags := LoadTemplates("", false)
+	if diags.HasErrors() {
+		b.Fatalf("loading templates failed with %d error(s)", diags.Count(SeverityError))
+	}
+	config := &Config{OutputDir: filepath.Join(dir, "out"), Index: IndexFlat}
+	selected, err := resolveTargets(config, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := &RenderContext{Templates: templates, Config: config}
+
+	for _, run := range []struct {
+		name string
+		jobs int
+	}{{"serial", 1}, {"parallel", runtime.NumCPU()}} {
+		jobs := run.jobs
+		b.Run(run.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// Start from an empty directory so every file is written.
+				b.StopTimer()
+				if err := os.RemoveAll(config.OutputDir); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if diags := GenerateOutputs(schemas, selected, ctx, true, jobs, false); diags.HasErrors() {
+					b.Fatalf("generation failed with %d error(s)", diags.Count(SeverityError))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package generate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja/file"
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// bundledFile is a schema file bundled together with the local modules it
+// imports, plus the source map needed to tell which code came from where.
+type bundledFile struct {
+	// entry is the absolute path of the schema file that was bundled.
+	entry string
+	code  string
+	// outDir is the directory source map paths are relative to.
+	outDir    string
+	sourceMap *sourcemap.Consumer
+	// sources caches original files read for their comments.
+	sources map[string]string
+	// inputs are the absolute paths of every file in the bundle, the entry
+	// included, and of those that would change how its imports resolve; the
+	// cache uses them to notice edits to imported modules.
+	inputs parseInputs
+}
+
+// monkkoExternalPlugin keeps @monkko/orm imports out of the bundle. Packages
+// are external anyway, but a tsconfig `paths` entry pointing at the ORM
+// source in a monorepo would otherwise pull the whole ORM in.
+var monkkoExternalPlugin = api.Plugin{
+	Name: "monkko-external",
+	Setup: func(build api.PluginBuild) {
+		build.OnResolve(api.OnResolveOptions{Filter: `^@monkko/orm(/.*)?$`},
+			func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				return api.OnResolveResult{Path: args.Path, External: true}, nil
+			})
+	},
+}
+
+// bundleSchemaFile uses esbuild's Build API to bundle a schema file with its
+// relative and tsconfig `paths` imports into a single CommonJS program.
+// esbuild warnings are returned alongside a successful bundle.
+func bundleSchemaFile(filename string) (*bundledFile, Diagnostics) {
+	entry, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, Diagnostics{newError(filename, CodeReadFailed, "failed to resolve path: %v", err)}
+	}
+
+	// Nothing is written to disk; the outfile only anchors source map paths.
+	outfile := entry + ".js"
+	result := api.Build(api.BuildOptions{
+		EntryPoints: []string{entry},
+		Outfile:     outfile,
+		Bundle:      true,
+		Write:       false,
+		Format:      api.FormatCommonJS,
+		Platform:    api.PlatformNode,
+		Packages:    api.PackagesExternal,
+		Sourcemap:   api.SourceMapExternal,
+		Metafile:    true,
+		LogLevel:    api.LogLevelSilent,
+		Plugins:     []api.Plugin{monkkoExternalPlugin},
+	})
+	diags := esbuildDiagnostics(result.Warnings, filename, SeverityWarning)
+	if len(result.Errors) > 0 {
+		return nil, append(diags, esbuildDiagnostics(result.Errors, filename, SeverityError)...)
+	}
+
+	bundle := &bundledFile{
+		entry:  entry,
+		outDir: filepath.Dir(outfile),
+	}
+	for _, out := range result.OutputFiles {
+		if strings.HasSuffix(out.Path, ".map") {
+			bundle.sourceMap, err = sourcemap.Parse("", out.Contents)
+			if err != nil {
+				return nil, append(diags, newError(filename, CodeBuildFailed, "failed to read source map: %v", err))
+			}
+		} else {
+			bundle.code = string(out.Contents)
+		}
+	}
+	if bundle.sourceMap == nil {
+		return nil, append(diags, newError(filename, CodeBuildFailed, "esbuild produced no source map"))
+	}
+
+	var metafile struct {
+		Inputs map[string]struct {
+			Imports []struct {
+				Original string `json:"original"`
+			} `json:"imports"`
+		} `json:"inputs"`
+	}
+	if err := json.Unmarshal([]byte(result.Metafile), &metafile); err != nil {
+		return nil, append(diags, newError(filename, CodeBuildFailed, "failed to read esbuild metafile: %v", err))
+	}
+	var imports []bundleImport
+	for input, meta := range metafile.Inputs {
+		path, err := filepath.Abs(filepath.FromSlash(input))
+		if err != nil {
+			continue
+		}
+		bundle.inputs.files = append(bundle.inputs.files, path)
+		for _, imp := range meta.Imports {
+			if imp.Original != "" {
+				imports = append(imports, bundleImport{importer: path, specifier: imp.Original})
+			}
+		}
+	}
+	sort.Strings(bundle.inputs.files)
+	configs, absent := resolutionInputs(imports)
+	bundle.inputs.watched = append(configs, absent...)
+
+	return bundle, diags
+}
+
+// originalSource maps an offset in the bundled code back to the original
+// file (as an absolute path), line and column.
+func (b *bundledFile) originalSource(idx file.Idx) (string, int, int, bool) {
+	line, column := b.lineColumn(idx)
+	return b.originalPosition(line, column)
+}
+
+// originalPosition is originalSource for a 1-based line and 0-based column
+// in the bundled code.
+func (b *bundledFile) originalPosition(line, column int) (string, int, int, bool) {
+	source, _, srcLine, srcColumn, ok := b.sourceMap.Source(line, column)
+	if !ok || source == "" {
+		return "", 0, 0, false
+	}
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(b.outDir, filepath.FromSlash(source))
+	}
+	return filepath.Clean(source), srcLine, srcColumn, true
+}
+
+// diagnosticAt builds a diagnostic located at the original source of idx,
+// falling back to the entry file when the position cannot be mapped.
+func (b *bundledFile) diagnosticAt(idx file.Idx, severity Severity, code, message string) *Diagnostic {
+	d := &Diagnostic{
+		File:     displayPath(b.entry),
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+	}
+	if source, line, column, ok := b.originalSource(idx); ok {
+		d.File = displayPath(source)
+		d.Line = line
+		d.Column = column + 1
+	}
+	return d
+}
+
+// fromEntry reports whether the code at idx was written in the entry file
+// rather than in one of the modules it imports.
+func (b *bundledFile) fromEntry(idx file.Idx) bool {
+	source, _, _, ok := b.originalSource(idx)
+	return ok && source == b.entry
+}
+
+// displayPath shortens an absolute path to one relative to the working
+// directory when that is possible, for messages.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// lineColumn converts a 1-based goja offset into a 1-based line and a
+// 0-based column, the convention used by source maps.
+func (b *bundledFile) lineColumn(idx file.Idx) (int, int) {
+	offset := int(idx) - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(b.code) {
+		offset = len(b.code)
+	}
+	before := b.code[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - (strings.LastIndex(before, "\n") + 1)
+	return line, column
+}
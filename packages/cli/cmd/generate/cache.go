@@ -0,0 +1,244 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Version is the CLI version, set at build time with
+// `-ldflags "-X github.com/monkko/kit/cmd/generate.Version=..."`. Cache
+// entries written by another version are ignored, since the parser that
+// produced them may have changed.
+var Version = devVersion
+
+// devVersion marks a build without a version. Its cache entries are keyed
+// on the executable itself, since every rebuild may change the parser.
+const devVersion = "dev"
+
+// cacheDir holds one entry per schema file, relative to the working
+// directory (where monkko.config.json lives).
+const cacheDir = ".monkko/cache"
+
+// cacheFormat is bumped whenever Schema or cacheEntry change shape.
+const cacheFormat = 2
+
+// cacheEntry is the parse result of one schema file, valid for as long as
+// every input still has the recorded hash.
+type cacheEntry struct {
+	Key  string `json:"key"`
+	File string `json:"file"`
+	// Inputs maps the absolute path of each file that went into the result
+	// to its content hash. An empty hash records a file that must not
+	// exist, such as a tsconfig.json that would take over resolution.
+	Inputs      map[string]string `json:"inputs"`
+	Schemas     []Schema          `json:"schemas"`
+	Diagnostics Diagnostics       `json:"diagnostics,omitempty"`
+}
+
+// Cache stores the Schema IR parsed from each file so unchanged files are not
+// bundled and parsed again. A nil *Cache is valid and caches nothing.
+type Cache struct {
+	dir   string
+	key   string
+	debug bool
+	// readOnly caches use existing entries but neither store nor prune any,
+	// for --check and --dry-run.
+	readOnly bool
+	// used holds the entry names looked up this run; the rest are pruned.
+	// Files are parsed concurrently, so it is guarded by mu.
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// OpenCache returns the cache in dir. A read-only cache leaves dir as it is.
+func OpenCache(dir string, readOnly, debug bool) *Cache {
+	version := Version
+	if version == devVersion {
+		version += " " + executableStamp()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("monkko %s cache %d", version, cacheFormat)))
+	return &Cache{
+		dir:      dir,
+		key:      hex.EncodeToString(sum[:]),
+		debug:    debug,
+		readOnly: readOnly,
+		used:     make(map[string]bool),
+	}
+}
+
+// executableStamp identifies the running executable by its size and
+// modification time, which change whenever it is rebuilt.
+func executableStamp() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d %d", info.Size(), info.ModTime().UnixNano())
+}
+
+// entryName names the entry of a schema file after its absolute path.
+func entryName(filename string) string {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		path = filename
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:16]) + ".json"
+}
+
+// load returns the cached result for filename when none of its inputs have
+// changed since it was stored.
+func (c *Cache) load(filename string) ([]Schema, Diagnostics, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+	name := entryName(filename)
+	c.mu.Lock()
+	c.used[name] = true
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != c.key || entry.File != filename {
+		return nil, nil, false
+	}
+	for path, hash := range entry.Inputs {
+		current, exists, err := fileHash(path)
+		if err != nil || (exists && current != hash) || (!exists && hash != "") {
+			if c.debug {
+				fmt.Printf("♻️  %s changed; re-parsing %s\n", displayPath(path), filename)
+			}
+			return nil, nil, false
+		}
+	}
+	return entry.Schemas, entry.Diagnostics, true
+}
+
+// parseInputs are the files a parse result depends on.
+type parseInputs struct {
+	// files went into the bundle; the entry is valid while they are unchanged.
+	files []string
+	// watched are recorded as they are, missing or not, such as the tsconfig
+	// files of the bundled files and those whose creation would change how an
+	// import resolves.
+	watched []string
+}
+
+// store records the result of parsing filename from inputs. Failing to write
+// the cache never fails a run; the file is simply parsed again next time.
+func (c *Cache) store(filename string, inputs parseInputs, schemas []Schema, diags Diagnostics) {
+	if c == nil || c.readOnly || len(inputs.files) == 0 {
+		return
+	}
+	entry := cacheEntry{
+		Key:         c.key,
+		File:        filename,
+		Inputs:      make(map[string]string),
+		Schemas:     schemas,
+		Diagnostics: diags,
+	}
+	for _, path := range inputs.files {
+		hash, exists, err := fileHash(path)
+		if err != nil || !exists {
+			return
+		}
+		entry.Inputs[path] = hash
+	}
+	watched := inputs.watched
+	dirs := make(map[string]bool)
+	for _, path := range inputs.files {
+		if dir := filepath.Dir(path); !dirs[dir] {
+			dirs[dir] = true
+			watched = append(watched, nearestInputs(dir, "tsconfig.json", "jsconfig.json")...)
+			watched = append(watched, nearestInputs(dir, "package.json")...)
+		}
+	}
+	for _, path := range watched {
+		if _, ok := entry.Inputs[path]; ok {
+			continue
+		}
+		hash, _, err := fileHash(path)
+		if err != nil {
+			return
+		}
+		entry.Inputs[path] = hash
+	}
+
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(c.dir, 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(c.dir, entryName(filename)), data)
+	}
+	if err != nil && c.debug {
+		fmt.Printf("⚠️  Failed to cache %s: %v\n", filename, err)
+	}
+}
+
+// prune deletes entries no file looked up this run, such as those of deleted
+// schema files.
+func (c *Cache) prune() {
+	if c == nil || c.readOnly {
+		return
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".json") && !c.used[name] {
+			os.Remove(filepath.Join(c.dir, name))
+		}
+	}
+}
+
+// nearestInputs lists the files with one of names that esbuild would look at
+// for files in dir, from dir up to the first directory that has one: the
+// tsconfig.json or jsconfig.json that holds `paths`, or the package.json that
+// sets the module type. Those that do not exist are listed too, so adding
+// one invalidates the entry.
+func nearestInputs(dir string, names ...string) []string {
+	var paths []string
+	for {
+		found := false
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			paths = append(paths, path)
+			if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+				found = true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if found || parent == dir {
+			return paths
+		}
+		dir = parent
+	}
+}
+
+// ClearCache removes the cache directory, and its parent when nothing else
+// is left in it.
+func ClearCache(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	os.Remove(filepath.Dir(dir))
+	return nil
+}
@@ -0,0 +1,87 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files under dir from a map of slash-separated paths.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const cachedSchemaSource = `import { defineSchema, fields } from "@monkko/orm/schemas";
+import { Address } from "@lib/address";
+
+export const Shop = defineSchema({
+  name: "Shop",
+  db: "app",
+  collection: "shops",
+  fields: {
+    address: Address({ required: true }),
+  },
+});
+`
+
+const addressSource = `import { defineSubDocument, fields } from "@monkko/orm/schemas";
+
+export const Address = defineSubDocument({
+  street: fields.string({ required: true }),
+});
+`
+
+func TestCacheNoticesFileTakingOverImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// Comments and trailing commas are allowed in a tsconfig.
+		"tsconfig.json": `{
+  "compilerOptions": {
+    // lib/ is searched before vendor/
+    "paths": { "@lib/*": ["./lib/*", "./vendor/*",] },
+  },
+}`,
+		"vendor/address.ts":      addressSource,
+		"schemas/shop.monkko.ts": cachedSchemaSource,
+	})
+	file := filepath.Join(dir, "schemas", "shop.monkko.ts")
+	cache := OpenCache(filepath.Join(dir, cacheDir), false, false)
+	if _, diags := ParseSchemaFiles([]string{file}, cache, 1, false); diags.HasErrors() {
+		t.Fatalf("parse: %v", diags)
+	}
+	if _, _, ok := cache.load(file); !ok {
+		t.Fatal("unchanged file missed the cache")
+	}
+
+	// lib/address.ts now wins the `paths` lookup, so the entry is stale.
+	writeFiles(t, dir, map[string]string{"lib/address.ts": addressSource})
+	if _, _, ok := cache.load(file); ok {
+		t.Error("cache hit after a file took over an import")
+	}
+}
+
+func TestReadOnlyCacheWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"tsconfig.json":          `{ "compilerOptions": { "paths": { "@lib/*": ["./vendor/*"] } } }`,
+		"vendor/address.ts":      addressSource,
+		"schemas/shop.monkko.ts": cachedSchemaSource,
+	})
+	file := filepath.Join(dir, "schemas", "shop.monkko.ts")
+	cache := OpenCache(filepath.Join(dir, cacheDir), true, false)
+	if _, diags := ExtractSchemas([]string{file}, cache, 1, false); diags.HasErrors() {
+		t.Fatalf("extract: %v", diags)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".monkko")); !os.IsNotExist(err) {
+		t.Errorf("read-only cache created %s", filepath.Join(dir, ".monkko"))
+	}
+}
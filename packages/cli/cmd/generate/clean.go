@@ -0,0 +1,65 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var CleanCmd = &cobra.Command{
+	Use:          "clean",
+	Short:        "Remove generated outputs",
+	Long:         `Deletes every file listed in the manifest of each configured output directory, and the parse cache. Files edited since they were generated are kept.`,
+	RunE:         runClean,
+	SilenceUsage: true,
+}
+
+func init() {
+	CleanCmd.Flags().BoolVar(&debugFlag, "debug", false, "Enable debug output")
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	config, err := LoadConfig(debugFlag)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	selected, err := resolveTargets(config, nil)
+	if err != nil {
+		return err
+	}
+
+	// The default outputDir is cleaned even when no target writes there any
+	// more, so switching targets does not strand its old files.
+	dirs := []string{filepath.Clean(config.OutputDir)}
+	seen := map[string]bool{dirs[0]: true}
+	for _, tc := range selected {
+		dir := filepath.Clean(tc.OutputDir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var diags Diagnostics
+	removed := 0
+	for _, dir := range dirs {
+		n, dirDiags := CleanOutputDir(dir, debugFlag)
+		removed += n
+		diags = append(diags, dirDiags...)
+	}
+
+	if err := ClearCache(cacheDir); err != nil {
+		diags = append(diags, newError(cacheDir, CodeWriteFailed, "failed to remove cache: %v", err))
+	}
+
+	diags.Sort()
+	diags.Print(os.Stderr)
+	if diags.HasErrors() {
+		return fmt.Errorf("clean failed with %d error(s)", diags.Count(SeverityError))
+	}
+	fmt.Printf("🧹 Removed %d generated file(s)\n", removed)
+	return nil
+}
@@ -10,6 +10,7 @@ func LoadConfig(debug bool) (*Config, error) {
 	// Default config (fallback if no config file)
 	config := &Config{
 		OutputDir: "generated", // Fallback if no config file
+		Index:     IndexFlat,
 	}
 
 	// Try to load monkko.config.json
@@ -38,6 +39,25 @@ func LoadConfig(debug bool) (*Config, error) {
 		if userConfig.Excludes != nil {
 			config.Excludes = userConfig.Excludes
 		}
+		if userConfig.UseIgnoreFiles {
+			config.UseIgnoreFiles = true
+		}
+		if userConfig.Targets != nil {
+			config.Targets = userConfig.Targets
+		}
+		if userConfig.AdditionalProperties {
+			config.AdditionalProperties = true
+		}
+		if userConfig.TemplatesDir != "" {
+			config.TemplatesDir = userConfig.TemplatesDir
+		}
+		switch userConfig.Index {
+		case "":
+		case IndexFlat, IndexNamespaced, IndexNone:
+			config.Index = userConfig.Index
+		default:
+			return nil, fmt.Errorf("invalid index %q in monkko.config.json: expected %q, %q or %q", userConfig.Index, IndexFlat, IndexNamespaced, IndexNone)
+		}
 	} else {
 		return nil, fmt.Errorf("no monkko.config.json found. Run '@monkko/cli init' to create one")
 	}
@@ -0,0 +1,176 @@
+package generate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/token"
+)
+
+// This file is a small constant folder over the goja AST. It lets schema
+// literals use local constants (`max: MAX_AGE`), template literals
+// (`collection: `${PREFIX}_users``), unary minus and simple arithmetic or
+// string concatenation, all evaluated at generation time.
+
+// foldTemplateLiteral evaluates an untagged template literal whose
+// substitutions are themselves constant.
+func foldTemplateLiteral(n *ast.TemplateLiteral, scope *parseScope) (interface{}, error) {
+	if n.Tag != nil {
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "tagged template literals cannot be evaluated at generation time")
+	}
+
+	var sb strings.Builder
+	for i, elem := range n.Elements {
+		sb.WriteString(elem.Parsed.String())
+		if i < len(n.Expressions) {
+			val, err := convertASTNodeToValue(n.Expressions[i], scope)
+			if err != nil {
+				return nil, err
+			}
+			str, ok := jsToString(val)
+			if !ok {
+				return nil, scope.errorAt(n.Expressions[i], CodeUnsupportedSyntax, "template substitution must be a string, number or boolean constant")
+			}
+			sb.WriteString(str)
+		}
+	}
+	return sb.String(), nil
+}
+
+// foldUnaryExpression evaluates `-x` and `+x` on numeric constants.
+func foldUnaryExpression(n *ast.UnaryExpression, scope *parseScope) (interface{}, error) {
+	if n.Operator != token.MINUS && n.Operator != token.PLUS {
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "unary operator '%s' cannot be evaluated at generation time", n.Operator)
+	}
+
+	val, err := convertASTNodeToValue(n.Operand, scope)
+	if err != nil {
+		return nil, err
+	}
+	num, ok := floatValue(val)
+	if !ok {
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "unary '%s' expects a numeric constant", n.Operator)
+	}
+	if n.Operator == token.MINUS {
+		num = -num
+	}
+	return num, nil
+}
+
+// foldBinaryExpression evaluates arithmetic on numeric constants and `+`
+// concatenation when either side is a string, mirroring JavaScript.
+func foldBinaryExpression(n *ast.BinaryExpression, scope *parseScope) (interface{}, error) {
+	left, err := convertASTNodeToValue(n.Left, scope)
+	if err != nil {
+		return nil, err
+	}
+	right, err := convertASTNodeToValue(n.Right, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Operator == token.PLUS {
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+		if leftIsString || rightIsString {
+			l, lok := jsToString(left)
+			r, rok := jsToString(right)
+			if !lok || !rok {
+				return nil, scope.errorAt(n, CodeUnsupportedSyntax, "string concatenation expects string, number or boolean constants")
+			}
+			return l + r, nil
+		}
+	}
+
+	l, lok := floatValue(left)
+	r, rok := floatValue(right)
+	if !lok || !rok {
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "operator '%s' expects numeric constants", n.Operator)
+	}
+
+	var result float64
+	switch n.Operator {
+	case token.PLUS:
+		result = l + r
+	case token.MINUS:
+		result = l - r
+	case token.MULTIPLY:
+		result = l * r
+	case token.SLASH:
+		result = l / r
+	case token.REMAINDER:
+		result = math.Mod(l, r)
+	case token.EXPONENT:
+		result = math.Pow(l, r)
+	default:
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "operator '%s' cannot be evaluated at generation time", n.Operator)
+	}
+	return finiteNumber(result, n, scope)
+}
+
+// finiteNumber rejects the Infinity and NaN that division by zero, overflow
+// or an out-of-range literal produce, since neither JSON nor the generated
+// code can spell them.
+func finiteNumber(num float64, node ast.Node, scope *parseScope) (interface{}, error) {
+	name := ""
+	switch {
+	case math.IsNaN(num):
+		name = "NaN"
+	case math.IsInf(num, 1):
+		name = "Infinity"
+	case math.IsInf(num, -1):
+		name = "-Infinity"
+	default:
+		return num, nil
+	}
+	return nil, scope.errorAt(node, CodeInvalidValue, "expression evaluates to %s; only finite numbers are supported", name)
+}
+
+// jsToString converts a folded primitive to its JavaScript string form.
+func jsToString(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64, float64:
+		num, _ := floatValue(v)
+		return jsNumber(num), true
+	}
+	return "", false
+}
+
+// describeNode names an AST node the way a schema author would recognise it.
+func describeNode(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.CallExpression:
+		return "function call"
+	case *ast.FunctionLiteral, *ast.ArrowFunctionLiteral:
+		return "function"
+	case *ast.RegExpLiteral:
+		return "regular expression literal"
+	case *ast.ConditionalExpression:
+		return "conditional expression"
+	case *ast.DotExpression:
+		return fmt.Sprintf("property access '.%s'", n.Identifier.Name.String())
+	case *ast.BracketExpression:
+		return "computed property access"
+	case *ast.PropertyShort:
+		return "shorthand property with a default"
+	}
+	return fmt.Sprintf("%T expression", node)
+}
+
+// describeCallee renders a dotted callee such as `utils.trim` for messages.
+func describeCallee(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return e.Name.String()
+	case *ast.DotExpression:
+		return describeCallee(e.Left) + "." + e.Identifier.Name.String()
+	}
+	return "(expression)"
+}
@@ -0,0 +1,51 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestNonFiniteConstantsAreRejected(t *testing.T) {
+	tests := []struct {
+		expr string
+		// want is the reported value, or "" when the expression folds.
+		want string
+	}{
+		{"1 / LIMIT", "Infinity"},
+		{"-1 / LIMIT", "-Infinity"},
+		{"LIMIT / LIMIT", "NaN"},
+		{"10 ** 400", "Infinity"},
+		{"-(2 ** 2000)", "Infinity"},
+		{"-5 / 2", ""},
+	}
+	for i, tt := range tests {
+		dir := t.TempDir()
+		source := fmt.Sprintf(`import { defineSchema, fields } from "@monkko/orm/schemas";
+
+const LIMIT = 0;
+
+export const Limit = defineSchema({
+  name: "Limit",
+  db: "app",
+  collection: "limits",
+  fields: {
+    value: fields.number({ max: %s }),
+  },
+});
+`, tt.expr)
+		writeFiles(t, dir, map[string]string{"limit.monkko.ts": source})
+		_, diags := ParseSchemaFiles([]string{filepath.Join(dir, "limit.monkko.ts")}, nil, 1, false)
+
+		if tt.want == "" {
+			if diags.HasErrors() {
+				t.Errorf("%d: %s: %v", i, tt.expr, diags)
+			}
+			continue
+		}
+		want := fmt.Sprintf("expression evaluates to %s; only finite numbers are supported", tt.want)
+		if len(diags) != 1 || diags[0].Code != CodeInvalidValue || diags[0].Message != want || diags[0].Line != 10 {
+			t.Errorf("%d: %s: got %v; want %q at line 10", i, tt.expr, diags, want)
+		}
+	}
+}
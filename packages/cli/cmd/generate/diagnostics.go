@@ -0,0 +1,175 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic codes, stable so that tooling can match on them.
+const (
+	CodeBuildFailed          = "build-failed"
+	CodeBuildWarning         = "build-warning"
+	CodeSyntaxError          = "syntax-error"
+	CodeUnsupportedSyntax    = "unsupported-syntax"
+	CodeUnresolvedIdentifier = "unresolved-identifier"
+	CodeInvalidDefinition    = "invalid-definition"
+	CodeUnknownFieldType     = "unknown-field-type"
+	CodeUnknownProperty      = "unknown-property"
+	CodeInvalidValue         = "invalid-value"
+	CodeMissingProperty      = "missing-property"
+	CodeConflictingOptions   = "conflicting-options"
+	CodeInvalidFieldName     = "invalid-field-name"
+	CodeDanglingReference    = "dangling-reference"
+	CodeCrossDatabaseRef     = "cross-database-reference"
+	CodeDuplicateSchema      = "duplicate-schema"
+	CodeReadFailed           = "read-failed"
+	CodeTemplateFailed       = "template-failed"
+	CodeWriteFailed          = "write-failed"
+	CodeUnmanagedFile        = "unmanaged-file"
+	CodeEditedOutput         = "edited-output"
+)
+
+// Diagnostic is a problem found while generating, located in the original
+// TypeScript source where possible. Line and Column are 1-based; zero means
+// the position is unknown.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+// Error lets a Diagnostic travel through code that returns plain errors.
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s[%s]: %s", d.Severity, d.Code, d.Message)
+	}
+	return fmt.Sprintf("%s: %s[%s]: %s", location, d.Severity, d.Code, d.Message)
+}
+
+// newError creates an error diagnostic without a source position.
+func newError(file, code, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{
+		File:     file,
+		Severity: SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// asDiagnostic recovers the Diagnostic carried by err, or wraps a plain error
+// as one attributed to file.
+func asDiagnostic(err error, file, code string) Diagnostic {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		return *d
+	}
+	return newError(file, code, "%s", err.Error())
+}
+
+// esbuildDiagnostics converts esbuild messages, whose columns are 0-based.
+func esbuildDiagnostics(messages []api.Message, fallbackFile string, severity Severity) Diagnostics {
+	var diags Diagnostics
+	for _, msg := range messages {
+		d := Diagnostic{
+			File:     fallbackFile,
+			Severity: severity,
+			Code:     CodeBuildFailed,
+			Message:  msg.Text,
+		}
+		if severity == SeverityWarning {
+			// esbuild's own IDs, such as "tsconfig.json", are not codes
+			// tooling can rely on, so they only name the check.
+			d.Code = CodeBuildWarning
+			if msg.ID != "" {
+				d.Message = fmt.Sprintf("%s [%s]", msg.Text, msg.ID)
+			}
+		}
+		if msg.Location != nil {
+			d.File = msg.Location.File
+			d.Line = msg.Location.Line
+			d.Column = msg.Location.Column + 1
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// Diagnostics is the combined report of every phase of a generate run.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) HasErrors() bool {
+	return d.Count(SeverityError) > 0
+}
+
+func (d Diagnostics) Count(severity Severity) int {
+	n := 0
+	for _, diag := range d {
+		if diag.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Unique drops repeats of a diagnostic, keeping the first.
+func (d Diagnostics) Unique() Diagnostics {
+	seen := make(map[Diagnostic]bool, len(d))
+	var unique Diagnostics
+	for _, diag := range d {
+		if !seen[diag] {
+			seen[diag] = true
+			unique = append(unique, diag)
+		}
+	}
+	return unique
+}
+
+// Sort orders diagnostics by file and position so reports are stable.
+func (d Diagnostics) Sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].File != d[j].File {
+			return d[i].File < d[j].File
+		}
+		if d[i].Line != d[j].Line {
+			return d[i].Line < d[j].Line
+		}
+		return d[i].Column < d[j].Column
+	})
+}
+
+// Print writes every diagnostic followed by a summary line.
+func (d Diagnostics) Print(w io.Writer) {
+	if len(d) == 0 {
+		return
+	}
+	for _, diag := range d {
+		icon := "❌"
+		if diag.Severity == SeverityWarning {
+			icon = "⚠️ "
+		}
+		fmt.Fprintf(w, "%s %s\n", icon, diag)
+	}
+	fmt.Fprintf(w, "\nFound %d error(s) and %d warning(s)\n", d.Count(SeverityError), d.Count(SeverityWarning))
+}
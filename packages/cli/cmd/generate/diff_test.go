@@ -0,0 +1,133 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns "1\n" to "n\n", with the lines in replace swapped
+// for their replacement.
+func numberedLines(n int, replace map[int]string) []byte {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			sb.WriteString(line + "\n")
+		} else {
+			fmt.Fprintf(&sb, "%d\n", i)
+		}
+	}
+	return []byte(sb.String())
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []byte
+		want          string
+	}{
+		{
+			name:   "unchanged",
+			before: []byte("a\n"),
+			after:  []byte("a\n"),
+			want:   "",
+		},
+		{
+			name:   "missing file created",
+			before: nil,
+			after:  []byte("a\nb\n"),
+			want:   "--- /dev/null\n+++ b/f.ts\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "file deleted",
+			before: []byte("a\nb\n"),
+			after:  nil,
+			want:   "--- a/f.ts\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:   "empty file filled",
+			before: []byte{},
+			after:  []byte("a\nb\n"),
+			want:   "--- a/f.ts\n+++ b/f.ts\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "file emptied",
+			before: []byte("a\nb\n"),
+			after:  []byte{},
+			want:   "--- a/f.ts\n+++ b/f.ts\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:   "final newline added",
+			before: []byte("a\nb"),
+			after:  []byte("a\nb\n"),
+			want:   "--- a/f.ts\n+++ b/f.ts\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:   "final newline removed",
+			before: []byte("a\n"),
+			after:  []byte("a"),
+			want:   "--- a/f.ts\n+++ b/f.ts\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			// Six unchanged lines apart: the contexts touch, so one hunk.
+			name:   "close changes share a hunk",
+			before: numberedLines(20, nil),
+			after:  numberedLines(20, map[int]string{5: "five", 12: "twelve"}),
+			want: "--- a/f.ts\n+++ b/f.ts\n@@ -2,14 +2,14 @@\n 2\n 3\n 4\n-5\n+five\n" +
+				" 6\n 7\n 8\n 9\n 10\n 11\n-12\n+twelve\n 13\n 14\n 15\n",
+		},
+		{
+			// Seven unchanged lines apart: two hunks, as diff -u prints.
+			name:   "distant changes get their own hunks",
+			before: numberedLines(20, nil),
+			after:  numberedLines(20, map[int]string{5: "five", 13: "thirteen"}),
+			want: "--- a/f.ts\n+++ b/f.ts\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n" +
+				"@@ -10,7 +10,7 @@\n 10\n 11\n 12\n-13\n+thirteen\n 14\n 15\n 16\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("f.ts", tt.before, tt.after); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnifiedDiffFallsBackToReplacement(t *testing.T) {
+	// Every line differs, so the edit search gives up and the file is shown
+	// as removed and added wholesale, in one hunk.
+	lines := maxDiffEdits/2 + 10
+	var before, after strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&before, "old %d\n", i)
+		fmt.Fprintf(&after, "new %d\n", i)
+	}
+	got := unifiedDiff("f.ts", []byte(before.String()), []byte(after.String()))
+
+	header := fmt.Sprintf("--- a/f.ts\n+++ b/f.ts\n@@ -1,%d +1,%d @@\n", lines, lines)
+	if !strings.HasPrefix(got, header) {
+		t.Fatalf("diff starts %q; want %q", got[:len(header)], header)
+	}
+	body := strings.Split(strings.TrimSuffix(strings.TrimPrefix(got, header), "\n"), "\n")
+	if len(body) != 2*lines {
+		t.Fatalf("diff has %d line(s); want %d", len(body), 2*lines)
+	}
+	for i, line := range body {
+		want := fmt.Sprintf("-old %d", i)
+		if i >= lines {
+			want = fmt.Sprintf("+new %d", i-lines)
+		}
+		if line != want {
+			t.Fatalf("line %d of the diff is %q; want %q", i, line, want)
+		}
+	}
+}
+
+func TestUnifiedDiffIsMinimalBelowLimit(t *testing.T) {
+	// A single insertion into a long file stays a one-line change.
+	before := numberedLines(3000, nil)
+	after := append([]byte("0\n"), before...)
+	want := "--- a/f.ts\n+++ b/f.ts\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n"
+	if got := unifiedDiff("f.ts", before, after); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package generate
+
+import (
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/dop251/goja/file"
+)
+
+// declarationKeywords may sit between a JSDoc comment and the name it documents,
+// as in `/** ... */ export const User = ...`.
+var declarationKeywords = map[string]bool{
+	"export":  true,
+	"default": true,
+	"const":   true,
+	"let":     true,
+	"var":     true,
+}
+
+// leadingDoc returns the text of the JSDoc comment directly above the original
+// source of idx. esbuild drops comments from the bundle, so the comment is read
+// from the TypeScript file the source map points at.
+func (b *bundledFile) leadingDoc(idx file.Idx) string {
+	source, line, column, ok := b.originalSource(idx)
+	if !ok {
+		return ""
+	}
+	content, ok := b.sourceContent(source)
+	if !ok {
+		return ""
+	}
+	offset, ok := byteOffset(content, line, column)
+	if !ok {
+		return ""
+	}
+	return jsDocBefore(content[:offset])
+}
+
+// sourceContent reads an original source file once per bundle.
+func (b *bundledFile) sourceContent(path string) (string, bool) {
+	if content, ok := b.sources[path]; ok {
+		return content, true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	if b.sources == nil {
+		b.sources = make(map[string]string)
+	}
+	b.sources[path] = string(data)
+	return b.sources[path], true
+}
+
+// byteOffset converts a 1-based line and a 0-based UTF-16 column, as used by
+// source maps, into a byte offset in content.
+func byteOffset(content string, line, column int) (int, bool) {
+	offset := 0
+	for l := 1; l < line; l++ {
+		next := strings.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			return 0, false
+		}
+		offset += next + 1
+	}
+	for units := 0; units < column && offset < len(content); {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset, true
+}
+
+// jsDocBefore finds a `/** ... */` comment at the end of text, skipping
+// whitespace and declaration keywords, and returns its cleaned-up body.
+func jsDocBefore(text string) string {
+	rest := strings.TrimRight(text, " \t\r\n")
+	for {
+		cut := strings.LastIndexAny(rest, " \t\r\n")
+		word := rest[cut+1:]
+		if !declarationKeywords[word] {
+			break
+		}
+		rest = strings.TrimRight(rest[:cut+1], " \t\r\n")
+	}
+
+	if !strings.HasSuffix(rest, "*/") {
+		return ""
+	}
+	start := strings.LastIndex(rest, "/**")
+	if start < 0 || strings.Contains(rest[start+3:len(rest)-2], "*/") {
+		return ""
+	}
+
+	body := rest[start+3 : len(rest)-2]
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// jsDoc renders text as a JSDoc comment at the given indentation.
+func jsDoc(text, indent string) string {
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return "/** " + text + " */"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("/**\n")
+	for _, line := range lines {
+		sb.WriteString(indent + " *")
+		if line != "" {
+			sb.WriteString(" " + line)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + " */")
+	return sb.String()
+}
@@ -0,0 +1,134 @@
+package generate
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+)
+
+// esbuild rewrites every ESM export form into the same CommonJS shapes:
+//
+//	export const User = ...        -> __export(x_exports, { User: () => User })
+//	export { User as Account }     -> __export(x_exports, { Account: () => User })
+//	export default defineSchema()  -> var x_default = ...; __export(x_exports, { default: () => x_default })
+//
+// Hand-written CommonJS keeps `exports.X = ...`, `module.exports.X = ...` and
+// `module.exports = ...`. Parentheses and `satisfies` are already gone from
+// the bundled output, so they need no handling here.
+
+// collectExportNames maps each local binding of the entry module to the name
+// it is exported under, read from esbuild's `__export(...)` helper call. The
+// entry's exports object is the one handed to `module.exports = __toCommonJS(...)`.
+func collectExportNames(program *ast.Program) map[string]string {
+	exportNames := make(map[string]string)
+
+	entryExports := ""
+	for _, stmt := range program.Body {
+		exprStmt, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		assignExpr, ok := exprStmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			continue
+		}
+		if target, ok := exportTarget(assignExpr.Left); !ok || target != "default" {
+			continue
+		}
+		if call, ok := assignExpr.Right.(*ast.CallExpression); ok && len(call.ArgumentList) == 1 {
+			callee, isIdent := call.Callee.(*ast.Identifier)
+			arg, argIsIdent := call.ArgumentList[0].(*ast.Identifier)
+			if isIdent && argIsIdent && callee.Name.String() == "__toCommonJS" {
+				entryExports = arg.Name.String()
+			}
+		}
+	}
+	if entryExports == "" {
+		return exportNames
+	}
+
+	for _, stmt := range program.Body {
+		exprStmt, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		call, ok := exprStmt.Expression.(*ast.CallExpression)
+		if !ok || len(call.ArgumentList) != 2 {
+			continue
+		}
+		if callee, ok := call.Callee.(*ast.Identifier); !ok || callee.Name.String() != "__export" {
+			continue
+		}
+		if target, ok := call.ArgumentList[0].(*ast.Identifier); !ok || target.Name.String() != entryExports {
+			continue
+		}
+		exportsObj, ok := call.ArgumentList[1].(*ast.ObjectLiteral)
+		if !ok {
+			continue
+		}
+
+		for _, propNode := range exportsObj.Value {
+			prop, ok := propNode.(*ast.PropertyKeyed)
+			if !ok {
+				continue
+			}
+			exportName, err := getKeyFromPropertyKeyed(prop)
+			if err != nil {
+				continue
+			}
+			getter, ok := prop.Value.(*ast.ArrowFunctionLiteral)
+			if !ok {
+				continue
+			}
+			body, ok := getter.Body.(*ast.ExpressionBody)
+			if !ok {
+				continue
+			}
+			local, ok := body.Expression.(*ast.Identifier)
+			if !ok {
+				continue
+			}
+			// A binding exported under several names keeps its first named export.
+			if existing, ok := exportNames[local.Name.String()]; !ok || existing == "default" {
+				exportNames[local.Name.String()] = exportName
+			}
+		}
+	}
+
+	return exportNames
+}
+
+// exportTarget recognises the CommonJS assignment targets `exports.X`,
+// `module.exports.X` and `module.exports`, returning the export name ("default"
+// for the whole module).
+func exportTarget(expr ast.Expression) (string, bool) {
+	dotExpr, ok := expr.(*ast.DotExpression)
+	if !ok {
+		return "", false
+	}
+	if isModuleExports(dotExpr) {
+		return "default", true
+	}
+	if obj, ok := dotExpr.Left.(*ast.Identifier); ok && obj.Name.String() == "exports" {
+		return dotExpr.Identifier.Name.String(), true
+	}
+	if obj, ok := dotExpr.Left.(*ast.DotExpression); ok && isModuleExports(obj) {
+		return dotExpr.Identifier.Name.String(), true
+	}
+	return "", false
+}
+
+func isModuleExports(dotExpr *ast.DotExpression) bool {
+	module, ok := dotExpr.Left.(*ast.Identifier)
+	return ok && module.Name.String() == "module" && dotExpr.Identifier.Name.String() == "exports"
+}
+
+// defaultSchemaName derives a schema name for a default export from its file
+// name, e.g. "user-profile.monkko.ts" becomes "UserProfile".
+func defaultSchemaName(filename string) string {
+	base := filepath.Base(filename)
+	base = strings.TrimSuffix(base, ".monkko.ts")
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return pascal(base)
+}
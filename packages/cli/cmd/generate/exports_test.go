@@ -0,0 +1,67 @@
+package generate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaNamesFromExports(t *testing.T) {
+	const esm = `import { defineSchema, fields } from "@monkko/orm/schemas";
+`
+	const commonJS = `const { defineSchema, fields } = require("@monkko/orm/schemas");
+`
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "named export",
+			source: esm + `export const User = defineSchema({ db: "app", fields: { email: fields.string() } });`,
+			want:   []string{"User"},
+		},
+		{
+			name:   "default export",
+			source: esm + `export default defineSchema({ db: "app", fields: { email: fields.string() } });`,
+			want:   []string{"UserProfile"},
+		},
+		{
+			name: "renamed export",
+			source: esm + `const User = defineSchema({ db: "app", fields: { email: fields.string() } });
+export { User as Account };`,
+			want: []string{"Account"},
+		},
+		{
+			name:   "satisfies",
+			source: esm + `export const User = (defineSchema({ db: "app", fields: { email: fields.string() } })) satisfies object;`,
+			want:   []string{"User"},
+		},
+		{
+			name: "module.exports object",
+			source: commonJS + `module.exports = {
+  User: defineSchema({ db: "app", fields: { email: fields.string() } }),
+  Post: defineSchema({ db: "app", fields: { title: fields.string() } }),
+};`,
+			want: []string{"User", "Post"},
+		},
+		{
+			name:   "exports property",
+			source: commonJS + `exports.Account = defineSchema({ db: "app", fields: { email: fields.string() } });`,
+			want:   []string{"Account"},
+		},
+	}
+	for _, tt := range tests {
+		schemas, diags := parseFixture(t, map[string]string{"user-profile.monkko.ts": tt.source + "\n"}, "user-profile.monkko.ts")
+		if diags.HasErrors() {
+			t.Errorf("%s: %v", tt.name, diags)
+			continue
+		}
+		var got []string
+		for _, schema := range schemas {
+			got = append(got, schema.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: schemas %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,21 +4,22 @@ import (
 	"fmt"
 )
 
-// ExtractSchemas extracts schemas from .monkko.ts files using pure Go implementation
-func ExtractSchemas(files []string, debug bool) ([]Schema, error) {
-	if len(files) == 0 {
-		return []Schema{}, nil
-	}
-
+// ExtractSchemas extracts schemas from .monkko.ts files using pure Go implementation.
+// cache may be nil to parse every file; jobs bounds how many files are parsed
+// at once.
+func ExtractSchemas(files []string, cache *Cache, jobs int, debug bool) ([]Schema, Diagnostics) {
 	// Use the new Go-based parser
-	schemas, err := ParseSchemaFiles(files, debug)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract schemas: %w", err)
-	}
+	schemas, diags := ParseSchemaFiles(files, cache, jobs, debug)
+	cache.prune()
+
+	// A ref can point at a schema in any file, and two files can declare
+	// the same schema, so both are checked once every file has been parsed.
+	diags = append(diags, checkReferences(schemas)...)
+	diags = append(diags, checkDuplicates(schemas)...)
 
 	if debug {
 		fmt.Printf("📋 Extracted %d schema(s) from %d file(s)\n", len(schemas), len(files))
 	}
 
-	return schemas, nil
+	return schemas, diags
 }
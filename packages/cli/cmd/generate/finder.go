@@ -1,61 +1,215 @@
 package generate
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// FileDecision records why discovery picked up or skipped a path.
+type FileDecision struct {
+	// Path is slash-separated and relative to the working directory.
+	Path string
+	// Dir is set for a directory that was skipped as a whole.
+	Dir      bool
+	Included bool
+	// Rule names what decided, such as `excludes: **/dist/**`. It is empty
+	// for a file included because no includes are configured.
+	Rule string
+}
+
+// FindSchemaFiles returns the .monkko.ts files selected by the config's
+// includes and excludes.
 func FindSchemaFiles(config *Config, debug bool) ([]string, error) {
+	decisions, err := DiscoverSchemaFiles(config, debug)
+	if err != nil {
+		return nil, err
+	}
 	var files []string
+	for _, decision := range decisions {
+		if decision.Included {
+			files = append(files, filepath.FromSlash(decision.Path))
+		}
+	}
+	return files, nil
+}
 
-	// Determine search paths
-	searchPaths := config.Includes
-	if len(searchPaths) == 0 {
-		searchPaths = []string{"."} // Default to current directory
+// DiscoverSchemaFiles walks the directories the includes could match and
+// decides for every .monkko.ts file found whether it is used. A file must
+// match the includes (when there are any) and not the excludes; in each list
+// the last matching rule wins, so a `!` rule can carve out an exception.
+// With UseIgnoreFiles, paths matched by ignore files are skipped too. Excluded
+// directories are not walked, and are reported instead of the files inside
+// them.
+func DiscoverSchemaFiles(config *Config, debug bool) ([]FileDecision, error) {
+	includes, err := compileGlobs("includes", config.Includes, true)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compileGlobs("excludes", config.Excludes, false)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, searchPath := range searchPaths {
-		// Clean up glob patterns - filepath.Walk is already recursive
-		// Convert "src/schemas/**" to "src/schemas"
-		cleanPath := strings.TrimSuffix(searchPath, "/**")
-		cleanPath = strings.TrimSuffix(cleanPath, "/*")
+	var ignore *ignoreMatcher
+	if config.UseIgnoreFiles {
+		if ignore, err = newIgnoreMatcher(); err != nil {
+			return nil, err
+		}
+	}
 
-		// Check if directory exists
-		if _, err := os.Stat(cleanPath); os.IsNotExist(err) {
+	var positives []globRule
+	for _, rule := range includes {
+		if !rule.negate {
+			positives = append(positives, rule)
+		}
+	}
+
+	var decisions []FileDecision
+	seen := make(map[string]bool)
+	for _, root := range walkRoots(positives) {
+		// Check if the root exists
+		if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
 			if debug {
-				fmt.Printf("⚠️  Directory %s does not exist, skipping...\n", cleanPath)
+				fmt.Printf("⚠️  Directory %s does not exist, skipping...\n", root)
 			}
 			continue
 		}
 
-		err := filepath.Walk(cleanPath, func(path string, info os.FileInfo, err error) error {
+		err := filepath.WalkDir(filepath.FromSlash(root), func(path string, entry fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
+			rel := filepath.ToSlash(path)
+			if rel == "." {
+				return nil
+			}
 
-			// Check excludes
-			for _, exclude := range config.Excludes {
-				if matched, _ := filepath.Match(exclude, path); matched {
-					if info.IsDir() {
+			if entry.IsDir() {
+				if rule := excludedDir(excludes, rel); rule != nil {
+					decisions = append(decisions, FileDecision{Path: rel, Dir: true, Rule: "excludes: " + rule.Pattern})
+					return filepath.SkipDir
+				}
+				// The roots were named in the config, so only what is
+				// inside them can be ignored.
+				if ignore != nil && rel != root {
+					if entry.Name() == ".git" {
+						return filepath.SkipDir
+					}
+					rule, source, err := ignore.ignored(rel, true)
+					if err != nil {
+						return err
+					}
+					if rule != nil {
+						decisions = append(decisions, FileDecision{Path: rel, Dir: true, Rule: source + ": " + rule.Pattern})
 						return filepath.SkipDir
 					}
-					return nil
 				}
+				if len(positives) > 0 && !anyCouldMatchBeneath(positives, rel) {
+					return filepath.SkipDir
+				}
+				return nil
 			}
 
-			if strings.HasSuffix(path, ".monkko.ts") {
-				files = append(files, path)
+			if !strings.HasSuffix(rel, ".monkko.ts") || seen[rel] {
+				return nil
 			}
-
+			seen[rel] = true
+			decision := decideFile(includes, excludes, len(positives) > 0, rel)
+			if decision.Included && ignore != nil && rel != root {
+				rule, source, err := ignore.ignored(rel, false)
+				if err != nil {
+					return err
+				}
+				if rule != nil {
+					decision.Included = false
+					decision.Rule = source + ": " + rule.Pattern
+				}
+			}
+			decisions = append(decisions, decision)
 			return nil
 		})
-
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return files, nil
+	return decisions, nil
+}
+
+// walkRoots returns the directories to walk: the literal prefix of each
+// include, without those nested in another, or the working directory when
+// there are no includes.
+func walkRoots(includes []globRule) []string {
+	if len(includes) == 0 {
+		return []string{"."}
+	}
+	var roots []string
+	for _, rule := range includes {
+		roots = append(roots, rule.root())
+	}
+	sort.Strings(roots)
+
+	var result []string
+	for _, root := range roots {
+		nested := false
+		for _, kept := range result {
+			if kept == "." || root == kept || strings.HasPrefix(root, kept+"/") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// excludedDir returns the exclude rule that rules out everything in dir, or
+// nil when the directory has to be walked. A directory is still walked when a
+// `!` rule could re-include something inside it.
+func excludedDir(excludes []globRule, dir string) *globRule {
+	rule := lastMatch(excludes, dir, true)
+	if rule == nil || rule.negate {
+		return nil
+	}
+	for _, r := range excludes {
+		if r.negate && r.couldMatchBeneath(dir) {
+			return nil
+		}
+	}
+	return rule
+}
+
+func anyCouldMatchBeneath(rules []globRule, dir string) bool {
+	for _, rule := range rules {
+		if rule.couldMatchBeneath(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func decideFile(includes, excludes []globRule, hasIncludes bool, file string) FileDecision {
+	decision := FileDecision{Path: file, Included: !hasIncludes}
+	if rule := lastMatch(includes, file, false); rule != nil {
+		decision.Included = !rule.negate
+		decision.Rule = "includes: " + rule.Pattern
+	} else if hasIncludes {
+		decision.Rule = "not matched by includes"
+	}
+	if !decision.Included {
+		return decision
+	}
+	// A `!` exclude that re-includes the file is what decided.
+	if rule := lastMatch(excludes, file, false); rule != nil {
+		decision.Included = rule.negate
+		decision.Rule = "excludes: " + rule.Pattern
+	}
+	return decision
 }
@@ -0,0 +1,30 @@
+package generate
+
+import "testing"
+
+func TestDecideFileNamesTheDecidingRule(t *testing.T) {
+	includes, err := compileGlobs("includes", []string{"src/**"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	excludes, err := compileGlobs("excludes", []string{"**/dist/**", "!src/api/dist/**"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file     string
+		included bool
+		rule     string
+	}{
+		{"src/user.monkko.ts", true, "includes: src/**"},
+		{"lib/user.monkko.ts", false, "not matched by includes"},
+		{"src/web/dist/user.monkko.ts", false, "excludes: **/dist/**"},
+		{"src/api/dist/user.monkko.ts", true, "excludes: !src/api/dist/**"},
+	}
+	for _, tt := range tests {
+		decision := decideFile(includes, excludes, true, tt.file)
+		if decision.Included != tt.included || decision.Rule != tt.rule {
+			t.Errorf("%s: included %v by %q; want %v by %q", tt.file, decision.Included, decision.Rule, tt.included, tt.rule)
+		}
+	}
+}
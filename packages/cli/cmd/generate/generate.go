@@ -2,23 +2,40 @@ package generate
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 // Flag variables
-var debugFlag bool
+var (
+	debugFlag   bool
+	targetFlag  []string
+	checkFlag   bool
+	dryRunFlag  bool
+	noCacheFlag bool
+	jobsFlag    int
+)
 
 var Cmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate Standard Schema validation functions from Monkko schemas",
 	Long:  `Scans for *.monkko.ts files and generates corresponding Standard Schema validation functions.`,
 	RunE:  runGenerate,
+	// Schema problems are reported as diagnostics; the usage text would only bury them.
+	SilenceUsage: true,
 }
 
 func init() {
 	// Add the --debug flag
 	Cmd.Flags().BoolVar(&debugFlag, "debug", false, "Enable debug output")
+	Cmd.Flags().StringSliceVar(&targetFlag, "target", nil, "Output targets to generate, overriding the config (e.g. zod,ts-types,json-schema)")
+	Cmd.Flags().BoolVar(&checkFlag, "check", false, "Exit non-zero if generated files are out of date, printing a diff; writes nothing")
+	Cmd.Flags().IntVarP(&jobsFlag, "jobs", "j", 0, "Number of files to parse and write at once (default: number of CPUs)")
+	Cmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Parse every schema file instead of reusing results from "+cacheDir)
+	Cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "Print a diff of what would change without writing anything")
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -36,34 +53,99 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		fmt.Printf("🐛 Config loaded: %+v\n", config)
 	}
 
+	selected, err := resolveTargets(config, targetFlag)
+	if err != nil {
+		return err
+	}
+
+	// Templates are checked before any schema is parsed, so a broken
+	// override fails fast.
+	templates, diags := LoadTemplates(config.TemplatesDir, debugFlag)
+	if diags.HasErrors() {
+		diags.Sort()
+		diags.Print(os.Stderr)
+		return fmt.Errorf("generation failed with %d error(s)", diags.Count(SeverityError))
+	}
+
 	schemaFiles, err := FindSchemaFiles(config, debugFlag)
 	if err != nil {
 		return fmt.Errorf("failed to find schema files: %w", err)
 	}
 
+	// With no schema files left, generating still runs so that the outputs
+	// of deleted schemas are pruned, and --check reports them.
 	if len(schemaFiles) == 0 {
 		fmt.Println("⚠️  No .monkko.ts files found")
-		return nil
 	}
 
 	if debugFlag {
 		fmt.Printf("📄 Found %d schema file(s)\n", len(schemaFiles))
 	}
 
-	schemas, err := ExtractSchemas(schemaFiles, debugFlag)
-	if err != nil {
-		return fmt.Errorf("failed to extract schemas: %w", err)
+	// Every phase keeps going past individual failures; problems are
+	// collected and reported together once generation has finished.
+	var cache *Cache
+	if !noCacheFlag {
+		// --check and --dry-run change nothing on disk, the cache included.
+		cache = OpenCache(cacheDir, checkFlag || dryRunFlag, debugFlag)
 	}
+	schemas, extractDiags := ExtractSchemas(schemaFiles, cache, jobsFlag, debugFlag)
+	diags = append(diags, extractDiags...)
 
 	if debugFlag {
 		fmt.Printf("🐛 Extracted %d schemas\n", len(schemas))
 	}
 
-	err = GenerateTypes(schemas, config.OutputDir, debugFlag)
-	if err != nil {
-		return fmt.Errorf("failed to generate schemas: %w", err)
+	// Stale outputs are only pruned when every schema parsed, so that a
+	// broken schema file does not take its generated files with it.
+	prune := !diags.HasErrors()
+	ctx := &RenderContext{Templates: templates, Config: config}
+	if checkFlag || dryRunFlag {
+		return runCheck(schemas, selected, ctx, prune, diags)
+	}
+	diags = append(diags, GenerateOutputs(schemas, selected, ctx, prune, jobsFlag, debugFlag)...)
+
+	diags.Sort()
+	diags.Print(os.Stderr)
+	if diags.HasErrors() {
+		return fmt.Errorf("generation failed with %d error(s)", diags.Count(SeverityError))
 	}
 
-	fmt.Printf("✅ Generated Standard Schema validation functions for %d schema(s)\n", len(schemas))
+	names := make([]string, len(selected))
+	for i, tc := range selected {
+		names[i] = tc.Name
+	}
+	fmt.Printf("✅ Generated %s output for %d schema(s)\n", strings.Join(names, ", "), len(schemas))
+	return nil
+}
+
+// runCheck renders everything in memory and prints a unified diff of each
+// file that generating would create, change or delete. With --check, any
+// difference fails the command so CI catches schemas that were edited
+// without regenerating.
+func runCheck(schemas []Schema, selected []TargetConfig, ctx *RenderContext, prune bool, diags Diagnostics) error {
+	outputs, renderDiags := RenderOutputs(schemas, selected, ctx, debugFlag)
+	diags = append(diags, renderDiags...)
+	changes, planDiags := PlanOutputs(outputs, prune)
+	diags = append(diags, planDiags...)
+
+	for _, change := range changes {
+		fmt.Print(unifiedDiff(filepath.ToSlash(change.Path), change.Before, change.After))
+	}
+
+	diags.Sort()
+	diags.Print(os.Stderr)
+	if diags.HasErrors() {
+		return fmt.Errorf("generation failed with %d error(s)", diags.Count(SeverityError))
+	}
+
+	switch {
+	case len(changes) == 0:
+		fmt.Printf("✅ Generated output is up to date for %d schema(s)\n", len(schemas))
+	case checkFlag:
+		return fmt.Errorf("%d generated file(s) are out of date; run monkko generate", len(changes))
+	default:
+		fmt.Printf("🔍 %d generated file(s) would change\n", len(changes))
+	}
 	return nil
 }
@@ -1,112 +1,332 @@
 package generate
 
 import (
-	_ "embed"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"strings"
-	"text/template"
+	"path/filepath"
+	"strconv"
 )
 
-//go:embed templates/schema.tmpl
-var schemaTemplate string
+// OutputDir is the rendered content of one output directory, which may be
+// shared by several targets.
+type OutputDir struct {
+	Dir string
+	// Targets are the targets that rendered into Dir this run.
+	Targets []string
+	Files   []GeneratedFile
+}
 
-//go:embed templates/utils.tmpl
-var utilsTemplate string
+// GeneratedFile is an OutputFile together with the target that rendered it.
+type GeneratedFile struct {
+	OutputFile
+	Target string
+}
 
-func GenerateTypes(schemas []Schema, outputDir string, debug bool) error {
-	if debug {
-		fmt.Printf("🔧 Creating output directory: %s\n", outputDir)
+// owns reports whether a manifest entry belongs to a target that ran, and so
+// may be pruned when the run no longer produces it. Files of targets left out
+// by --target are kept.
+func (out OutputDir) owns(entry ManifestEntry) bool {
+	for _, target := range out.Targets {
+		if entry.Target == target {
+			return true
+		}
 	}
+	return false
+}
 
-	// Create output directory if it doesn't exist
-	err := os.MkdirAll(outputDir, 0755)
-	if err != nil {
-		return err
+// GenerateOutputs renders every schema with each selected target and writes
+// the files to the target's output directory. A file that fails to render or
+// write is reported without stopping the others. With prune set, files that
+// an earlier run of the same targets generated but this one did not are
+// deleted; runs that failed to parse some schemas leave them in place. Files
+// are written on up to jobs workers.
+func GenerateOutputs(schemas []Schema, selected []TargetConfig, ctx *RenderContext, prune bool, jobs int, debug bool) Diagnostics {
+	outputs, diags := RenderOutputs(schemas, selected, ctx, debug)
+	for _, out := range outputs {
+		diags = append(diags, writeOutputDir(out, prune, jobs, debug)...)
 	}
+	return diags
+}
 
-	// Always generate utils file since all schemas have _id field that uses ObjectIdSchema
-	err = generateUtilsFile(outputDir, debug)
-	if err != nil {
-		return fmt.Errorf("failed to generate utils file: %w", err)
+// RenderOutputs renders the files of every selected target in memory,
+// grouped by output directory.
+func RenderOutputs(schemas []Schema, selected []TargetConfig, ctx *RenderContext, debug bool) ([]OutputDir, Diagnostics) {
+	var outputs []OutputDir
+	var diags Diagnostics
+	byDir := make(map[string]int)
+	// owners maps each output file to the target that produced it.
+	owners := make(map[string]string)
+
+	for _, tc := range selected {
+		target := targets[tc.Name]
+		dir := filepath.Clean(tc.OutputDir)
+		if debug {
+			fmt.Printf("🔧 Generating %s output in %s\n", target.Name(), dir)
+		}
+		files, renderDiags := renderTarget(target, schemas, dir, ctx)
+		diags = append(diags, renderDiags...)
+
+		i, ok := byDir[dir]
+		if !ok {
+			i = len(outputs)
+			byDir[dir] = i
+			outputs = append(outputs, OutputDir{Dir: dir})
+		}
+		outputs[i].Targets = append(outputs[i].Targets, target.Name())
+		for _, file := range files {
+			filename := filepath.Join(dir, file.Path)
+			if owner, exists := owners[filename]; exists {
+				// Schemas of one target clash only when they share a name
+				// or collection, which checkDuplicates already reported.
+				if owner != target.Name() {
+					diags = append(diags, newError(filename, CodeWriteFailed, "targets %s and %s both write this file; give one of them its own outputDir", owner, target.Name()))
+				}
+				continue
+			}
+			owners[filename] = target.Name()
+			outputs[i].Files = append(outputs[i].Files, GeneratedFile{OutputFile: file, Target: target.Name()})
+		}
 	}
+	return outputs, diags
+}
 
-	// Generate schemas for each schema
+func renderTarget(target Target, schemas []Schema, outputDir string, ctx *RenderContext) ([]OutputFile, Diagnostics) {
+	var diags Diagnostics
+	files, err := target.RenderShared(schemas, ctx)
+	if err != nil {
+		diags = append(diags, newError(outputDir, CodeTemplateFailed, "failed to generate %s shared files: %v", target.Name(), err))
+	}
 	for _, schema := range schemas {
-		content, err := generateSchemaContent(schema)
+		schemaFiles, err := target.RenderSchema(schema, ctx)
 		if err != nil {
-			return fmt.Errorf("failed to generate content for %s: %w", schema.Name, err)
+			diags = append(diags, newError(filepath.Join(outputDir, schema.Name), CodeTemplateFailed, "failed to generate %s content for %s: %v", target.Name(), schema.Name, err))
+			continue
 		}
+		files = append(files, schemaFiles...)
+	}
+	return files, diags
+}
 
-		filename := fmt.Sprintf("%s/%s.schema.ts", outputDir, schema.Name)
-		err = os.WriteFile(filename, []byte(content), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write file %s: %w", filename, err)
+// writeOutputDir writes the files of out and records them in its manifest.
+// Files the previous manifest does not list were not generated by Monkko and
+// are not overwritten. A directory without a manifest, such as one generated
+// before manifests existed, cannot tell, so its files are overwritten with a
+// warning.
+func writeOutputDir(out OutputDir, prune bool, jobs int, debug bool) Diagnostics {
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(out.Dir, 0755); err != nil {
+		return Diagnostics{newError(out.Dir, CodeWriteFailed, "failed to create output directory: %v", err)}
+	}
+
+	previous, err := readManifest(out.Dir)
+	if err != nil {
+		return Diagnostics{newError(filepath.Join(out.Dir, manifestName), CodeReadFailed, "%v; delete it to regenerate %s from scratch", err, out.Dir)}
+	}
+
+	// Files are written on up to jobs workers and gathered in order, so the
+	// manifest and diagnostics do not depend on scheduling.
+	type writeResult struct {
+		written bool
+		diags   Diagnostics
+	}
+	results := make([]writeResult, len(out.Files))
+	forEach(len(out.Files), jobs, func(i int) {
+		written, fileDiags := writeOutput(out.Dir, out.Files[i].OutputFile, previous, debug)
+		results[i] = writeResult{written, fileDiags}
+	})
+
+	var diags Diagnostics
+	manifest := newManifest()
+	for i, result := range results {
+		diags = append(diags, result.diags...)
+		if result.written {
+			file := out.Files[i]
+			manifest.Files[filepath.ToSlash(file.Path)] = ManifestEntry{Hash: contentHash(file.Content), Target: file.Target}
 		}
+	}
 
-		if debug {
-			fmt.Printf("  📝 %s\n", filename)
+	if previous != nil {
+		for _, path := range previous.sortedPaths() {
+			if _, ok := manifest.Files[path]; ok {
+				continue
+			}
+			entry := previous.Files[path]
+			if !prune || !out.owns(entry) {
+				manifest.Files[path] = entry
+				continue
+			}
+			kept, removeDiags := removeGenerated(out.Dir, path, entry.Hash)
+			diags = append(diags, removeDiags...)
+			if kept {
+				manifest.Files[path] = entry
+			} else if debug {
+				fmt.Printf("  🗑️  %s\n", filepath.Join(out.Dir, filepath.FromSlash(path)))
+			}
 		}
 	}
 
-	return nil
+	if err := manifest.write(out.Dir); err != nil {
+		diags = append(diags, newError(filepath.Join(out.Dir, manifestName), CodeWriteFailed, "failed to write manifest: %v", err))
+	}
+	return diags
 }
 
-func generateSchemaContent(schema Schema) (string, error) {
-	tmpl := template.Must(template.New("schema").Funcs(template.FuncMap{
-		"zodType": zodType,
-		"printf":  fmt.Sprintf,
-	}).Parse(schemaTemplate))
+// writeOutput writes one file of dir unless it already holds the same
+// content. written reports whether the file now holds its generated content
+// and belongs in the manifest.
+func writeOutput(dir string, file OutputFile, previous *Manifest, debug bool) (written bool, diags Diagnostics) {
+	path := filepath.ToSlash(file.Path)
+	filename := filepath.Join(dir, file.Path)
 
-	var result strings.Builder
-	err := tmpl.Execute(&result, schema)
-	if err != nil {
-		return "", err
+	// Files that already hold the new content are left untouched, so
+	// watchers and incremental builds only see real changes.
+	current, err := os.ReadFile(filename)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, Diagnostics{newError(filename, CodeReadFailed, "%v", err)}
+	}
+	if exists && bytes.Equal(current, file.Content) {
+		return true, nil
+	}
+	if _, generated := previous.lookup(path); previous != nil && !generated && exists {
+		return false, Diagnostics{newError(filename, CodeUnmanagedFile, "refusing to overwrite %s: it was not generated by monkko", path)}
+	}
+	if previous == nil && exists {
+		diags = append(diags, unrecordedOverwrite(filename, path))
 	}
 
-	return result.String(), nil
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return false, Diagnostics{newError(filename, CodeWriteFailed, "failed to create directory: %v", err)}
+	}
+	if err := writeFileAtomic(filename, file.Content); err != nil {
+		return false, Diagnostics{newError(filename, CodeWriteFailed, "failed to write file: %v", err)}
+	}
+	if debug {
+		fmt.Printf("  📝 %s\n", filename)
+	}
+	return true, diags
 }
 
-func zodType(schemaType string, required bool) string {
-	var zodSchema string
+// unrecordedOverwrite warns that an existing file is replaced in a directory
+// that has no manifest to say whether Monkko generated it.
+func unrecordedOverwrite(filename, path string) Diagnostic {
+	d := newError(filename, CodeUnmanagedFile, "overwriting %s: this directory has no %s to show it was generated by monkko", path, manifestName)
+	d.Severity = SeverityWarning
+	return d
+}
 
-	switch schemaType {
-	case "string":
-		zodSchema = "z.string()"
-	case "number":
-		zodSchema = "z.number()"
-	case "boolean":
-		zodSchema = "z.boolean()"
-	case "date":
-		zodSchema = "z.date()"
-	case "objectId":
-		zodSchema = "ObjectIdSchema"
-	default:
-		zodSchema = "z.any()"
+// writeFileAtomic replaces filename with content by writing a temporary file
+// next to it and renaming it into place, so that an interrupted run leaves
+// either the old file or the new one, never part of it.
+func writeFileAtomic(filename string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
 	}
+	// Removing the temporary file fails harmlessly once it has been renamed.
+	defer os.Remove(tmp.Name())
 
-	if !required {
-		zodSchema += ".optional()"
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
 	}
+	return os.Rename(tmp.Name(), filename)
+}
 
-	return zodSchema
+// OutputChange is a file whose content on disk differs from what generation
+// would leave there. Before is nil for a file that would be created and After
+// is nil for one that would be deleted.
+type OutputChange struct {
+	Path   string
+	Before []byte
+	After  []byte
 }
 
-// generateUtilsFile generates the shared utils file with ObjectIdSchema
-func generateUtilsFile(outputDir string, debug bool) error {
-	filename := fmt.Sprintf("%s/utils.ts", outputDir)
+// PlanOutputs compares rendered outputs with the disk without writing
+// anything, applying the same manifest rules as a real run.
+func PlanOutputs(outputs []OutputDir, prune bool) ([]OutputChange, Diagnostics) {
+	var changes []OutputChange
+	var diags Diagnostics
+	for _, out := range outputs {
+		previous, err := readManifest(out.Dir)
+		if err != nil {
+			diags = append(diags, newError(filepath.Join(out.Dir, manifestName), CodeReadFailed, "%v; delete it to regenerate %s from scratch", err, out.Dir))
+			continue
+		}
 
-	err := os.WriteFile(filename, []byte(utilsTemplate), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write utils file %s: %w", filename, err)
-	}
+		produced := make(map[string]bool)
+		for _, file := range out.Files {
+			path := filepath.ToSlash(file.Path)
+			filename := filepath.Join(out.Dir, file.Path)
+			produced[path] = true
 
-	if debug {
-		fmt.Printf("  📝 %s\n", filename)
+			before, err := os.ReadFile(filename)
+			exists := err == nil
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				diags = append(diags, newError(filename, CodeReadFailed, "%v", err))
+				continue
+			}
+			if exists && bytes.Equal(before, file.Content) {
+				continue
+			}
+			if _, generated := previous.lookup(path); previous != nil && !generated && exists {
+				diags = append(diags, newError(filename, CodeUnmanagedFile, "refusing to overwrite %s: it was not generated by monkko", path))
+			}
+			if previous == nil && exists {
+				diags = append(diags, unrecordedOverwrite(filename, path))
+			}
+			changes = append(changes, OutputChange{Path: filename, Before: before, After: file.Content})
+		}
+
+		if previous == nil || !prune {
+			continue
+		}
+		for _, path := range previous.sortedPaths() {
+			if produced[path] || !out.owns(previous.Files[path]) {
+				continue
+			}
+			filename := filepath.Join(out.Dir, filepath.FromSlash(path))
+			before, err := os.ReadFile(filename)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				diags = append(diags, newError(filename, CodeReadFailed, "%v", err))
+				continue
+			}
+			if contentHash(before) != previous.Files[path].Hash {
+				d := newError(filename, CodeEditedOutput, "not deleting %s: it was edited after it was generated", path)
+				d.Severity = SeverityWarning
+				diags = append(diags, d)
+				continue
+			}
+			changes = append(changes, OutputChange{Path: filename, Before: before})
+		}
 	}
+	return changes, diags
+}
 
-	return nil
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
 }
 
-// Note: Removed typeScriptType() and generateValidator() functions
-// as they are no longer needed with Zod schema generation
+// jsNumber formats n as a JavaScript number literal.
+func jsNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,167 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// generateInto runs the named targets into dir, all sharing it.
+func generateInto(t *testing.T, dir string, names ...string) Diagnostics {
+	t.Helper()
+	templates, diags := LoadTemplates("", false)
+	if diags.HasErrors() {
+		t.Fatalf("loading templates: %v", diags)
+	}
+	config := &Config{OutputDir: dir, Index: IndexFlat}
+	var selected []TargetConfig
+	for _, name := range names {
+		selected = append(selected, TargetConfig{Name: name, OutputDir: dir})
+	}
+	ctx := &RenderContext{Templates: templates, Config: config}
+	return GenerateOutputs([]Schema{sampleSchema}, selected, ctx, true, 1, false)
+}
+
+func TestNarrowedRunKeepsOtherTargetsFiles(t *testing.T) {
+	dir := t.TempDir()
+	if diags := generateInto(t, dir, "zod", "json-schema"); diags.HasErrors() {
+		t.Fatalf("full run: %v", diags)
+	}
+	zodFile := filepath.Join(dir, "Sample.schema.ts")
+	if _, err := os.Stat(zodFile); err != nil {
+		t.Fatalf("full run did not write %s: %v", zodFile, err)
+	}
+
+	// A run narrowed to json-schema must neither delete nor plan to delete
+	// the zod files sharing its directory.
+	templates, _ := LoadTemplates("", false)
+	ctx := &RenderContext{Templates: templates, Config: &Config{OutputDir: dir, Index: IndexFlat}}
+	outputs, diags := RenderOutputs([]Schema{sampleSchema}, []TargetConfig{{Name: "json-schema", OutputDir: dir}}, ctx, false)
+	if diags.HasErrors() {
+		t.Fatalf("render: %v", diags)
+	}
+	changes, diags := PlanOutputs(outputs, true)
+	if diags.HasErrors() || len(changes) != 0 {
+		t.Fatalf("narrowed check reported %d change(s): %v", len(changes), diags)
+	}
+
+	if diags := generateInto(t, dir, "json-schema"); diags.HasErrors() {
+		t.Fatalf("narrowed run: %v", diags)
+	}
+	if _, err := os.Stat(zodFile); err != nil {
+		t.Errorf("narrowed run deleted %s", zodFile)
+	}
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := manifest.Files["Sample.schema.ts"]; !ok || entry.Target != "zod" {
+		t.Errorf("manifest entry for Sample.schema.ts = %+v, %v; want it kept for zod", entry, ok)
+	}
+}
+
+func TestRunPrunesOwnStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	if diags := generateInto(t, dir, "zod", "json-schema"); diags.HasErrors() {
+		t.Fatalf("full run: %v", diags)
+	}
+	// Dropping a target from a full run leaves its files alone too; only the
+	// files a target that ran no longer produces are stale.
+	stale := filepath.Join(dir, "Old.schema.json")
+	if err := os.WriteFile(stale, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Files["Old.schema.json"] = ManifestEntry{Hash: contentHash([]byte("{}\n")), Target: "json-schema"}
+	if err := manifest.write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := generateInto(t, dir, "json-schema"); diags.HasErrors() {
+		t.Fatalf("narrowed run: %v", diags)
+	}
+	if _, err := os.Stat(stale); err == nil {
+		t.Errorf("stale json-schema file %s was not pruned", stale)
+	}
+}
+
+func TestOverwriteWithoutManifestWarns(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "Sample.schema.ts")
+	if err := os.WriteFile(existing, []byte("// older output\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diags := generateInto(t, dir, "zod")
+	if diags.HasErrors() {
+		t.Fatalf("run: %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeUnmanagedFile || diags[0].Severity != SeverityWarning {
+		t.Errorf("diagnostics = %v; want one %s warning", diags, CodeUnmanagedFile)
+	}
+
+	// With a manifest in place, an unlisted file is refused instead.
+	unlisted := filepath.Join(dir, "utils.ts")
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(manifest.Files, "utils.ts")
+	if err := manifest.write(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unlisted, []byte("// mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diags = generateInto(t, dir, "zod")
+	if !diags.HasErrors() || diags[0].Code != CodeUnmanagedFile {
+		t.Errorf("diagnostics = %v; want a %s error", diags, CodeUnmanagedFile)
+	}
+}
+
+func TestRunWithoutSchemasPrunesTheirFiles(t *testing.T) {
+	dir := t.TempDir()
+	if diags := generateInto(t, dir, "zod", "json-schema"); diags.HasErrors() {
+		t.Fatalf("full run: %v", diags)
+	}
+	// Deleting the last schema file leaves a run with no schemas at all.
+	templates, _ := LoadTemplates("", false)
+	ctx := &RenderContext{Templates: templates, Config: &Config{OutputDir: dir, Index: IndexFlat}}
+	selected := []TargetConfig{{Name: "zod", OutputDir: dir}, {Name: "json-schema", OutputDir: dir}}
+	if diags := GenerateOutputs(nil, selected, ctx, true, 1, false); diags.HasErrors() {
+		t.Fatalf("empty run: %v", diags)
+	}
+	for _, name := range []string{"Sample.schema.ts", "Sample.schema.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s was not pruned", name)
+		}
+	}
+}
+
+func TestCheckWithoutSchemasReportsStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	if diags := generateInto(t, dir, "zod"); diags.HasErrors() {
+		t.Fatalf("full run: %v", diags)
+	}
+	templates, _ := LoadTemplates("", false)
+	ctx := &RenderContext{Templates: templates, Config: &Config{OutputDir: dir, Index: IndexFlat}}
+	outputs, diags := RenderOutputs(nil, []TargetConfig{{Name: "zod", OutputDir: dir}}, ctx, false)
+	if diags.HasErrors() {
+		t.Fatalf("render: %v", diags)
+	}
+	changes, diags := PlanOutputs(outputs, true)
+	if diags.HasErrors() {
+		t.Fatalf("plan: %v", diags)
+	}
+	deleted := false
+	for _, change := range changes {
+		if filepath.Base(change.Path) == "Sample.schema.ts" && change.After == nil {
+			deleted = true
+		}
+	}
+	if !deleted {
+		t.Errorf("check with no schemas did not plan to delete Sample.schema.ts: %d change(s)", len(changes))
+	}
+}
@@ -0,0 +1,183 @@
+package generate
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globRule is one compiled includes/excludes pattern, with gitignore-style
+// semantics:
+//
+//   - `*` and `?` match within a path segment, `[...]` matches a character
+//     class (`[!...]` negates it) and `**` matches any number of segments;
+//   - a pattern without a `/` matches a name at any depth, unless the list is
+//     anchored (includes are: they name paths from the working directory);
+//   - a leading `!` negates the rule and a trailing `/` matches directories only;
+//   - a rule that matches a directory also matches everything inside it.
+//
+// Paths are slash-separated and relative to the working directory.
+type globRule struct {
+	// Pattern is the rule as written, for messages.
+	Pattern  string
+	negate   bool
+	dirOnly  bool
+	segments []string
+}
+
+// compileGlob parses a pattern from the config. Anchored patterns always
+// match from the working directory.
+func compileGlob(pattern string, anchored bool) (globRule, error) {
+	rule := globRule{Pattern: pattern}
+	p := pattern
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	anchored = anchored || strings.Contains(p, "/")
+	for strings.HasPrefix(p, "./") {
+		p = p[2:]
+	}
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || p == "." {
+		if rule.dirOnly || pattern == "." || pattern == "./" {
+			// "." or "./" selects the whole working directory.
+			rule.segments = []string{"**"}
+			rule.dirOnly = false
+			return rule, nil
+		}
+		return rule, fmt.Errorf("empty pattern")
+	}
+	if !anchored {
+		p = "**/" + p
+	}
+
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "" {
+			continue
+		}
+		segment = strings.ReplaceAll(segment, "[!", "[^")
+		// Consecutive ** are the same as one.
+		if segment == "**" && len(rule.segments) > 0 && rule.segments[len(rule.segments)-1] == "**" {
+			continue
+		}
+		if segment != "**" {
+			if _, err := path.Match(segment, ""); err != nil {
+				return rule, fmt.Errorf("bad pattern segment %q", segment)
+			}
+		}
+		rule.segments = append(rule.segments, segment)
+	}
+	return rule, nil
+}
+
+// compileGlobs compiles a config list, naming the list in errors. Config
+// patterns are relative to the working directory, so an absolute path, which
+// would otherwise silently match nothing, is an error.
+func compileGlobs(list string, patterns []string, anchored bool) ([]globRule, error) {
+	rules := make([]globRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if p := strings.TrimPrefix(pattern, "!"); strings.HasPrefix(p, "/") || filepath.IsAbs(p) {
+			return nil, fmt.Errorf("invalid pattern %q in %s: absolute paths are not supported; write it relative to the directory of monkko.config.json", pattern, list)
+		}
+		rule, err := compileGlob(pattern, anchored)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %v", pattern, list, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matches reports whether the rule matches p itself, or one of the
+// directories containing it.
+func (r globRule) matches(p string, isDir bool) bool {
+	segments := strings.Split(p, "/")
+	for n := len(segments); n > 0; n-- {
+		// Every proper prefix of p is a directory.
+		dir := isDir || n < len(segments)
+		if r.dirOnly && !dir {
+			continue
+		}
+		if matchSegments(r.segments, segments[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// couldMatchBeneath reports whether the rule might match something inside
+// directory dir, so that the walk has to look inside it.
+func (r globRule) couldMatchBeneath(dir string) bool {
+	if dir == "." || dir == "" {
+		return true
+	}
+	segments := strings.Split(dir, "/")
+	for i, pattern := range r.segments {
+		if pattern == "**" {
+			return true
+		}
+		if i >= len(segments) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, segments[i]); !ok {
+			return false
+		}
+	}
+	// The pattern ends at or above dir, so it matches dir or an ancestor.
+	return true
+}
+
+// root is the literal directory the rule's matches all live under: the
+// segments before the first one with a wildcard.
+func (r globRule) root() string {
+	var literal []string
+	for _, segment := range r.segments {
+		if segment == "**" || strings.ContainsAny(segment, `*?[\`) {
+			break
+		}
+		literal = append(literal, segment)
+	}
+	if len(literal) == 0 {
+		return "."
+	}
+	return strings.Join(literal, "/")
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// lastMatch applies rules in order, the last matching one winning as in a
+// .gitignore. It returns that rule, or nil when none matches.
+func lastMatch(rules []globRule, p string, isDir bool) *globRule {
+	var match *globRule
+	for i := range rules {
+		if rules[i].matches(p, isDir) {
+			match = &rules[i]
+		}
+	}
+	return match
+}
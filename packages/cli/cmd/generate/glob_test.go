@@ -0,0 +1,127 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		anchored bool
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		// ** at the start matches at any depth, including none.
+		{"**/dist/**", false, "dist/a.ts", false, true},
+		{"**/dist/**", false, "packages/api/dist/a.ts", false, true},
+		{"**/dist/**", false, "packages/distant/a.ts", false, false},
+		// ** in the middle matches zero or more whole segments.
+		{"src/**/models/*.ts", true, "src/models/user.ts", false, true},
+		{"src/**/models/*.ts", true, "src/a/b/models/user.ts", false, true},
+		{"src/**/models/*.ts", true, "src/models/sub/user.ts", false, false},
+		{"src/**/models/*.ts", true, "lib/src/models/user.ts", false, false},
+		// ** at the end matches everything inside.
+		{"src/**", true, "src/a.ts", false, true},
+		{"src/**", true, "src/a/b/c.ts", false, true},
+		{"src/**", true, "srcs/a.ts", false, false},
+		// Without a slash, unanchored patterns match a name at any depth...
+		{"*.draft.monkko.ts", false, "a/b/user.draft.monkko.ts", false, true},
+		{"*.draft.monkko.ts", false, "user.draft.monkko.ts", false, true},
+		// ...anchored ones only from the working directory.
+		{"*.draft.monkko.ts", true, "a/user.draft.monkko.ts", false, false},
+		{"*.draft.monkko.ts", true, "user.draft.monkko.ts", false, true},
+		// A slash anchors a pattern even in an unanchored list.
+		{"a/b", false, "a/b/c.ts", false, true},
+		{"a/b", false, "x/a/b/c.ts", false, false},
+		{"/build", false, "build/c.ts", false, true},
+		{"/build", false, "x/build/c.ts", false, false},
+		// A rule matching a directory covers what is inside it.
+		{"schemas", true, "schemas/user.monkko.ts", false, true},
+		{".", true, "a/b.ts", false, true},
+		// A trailing slash matches directories only.
+		{"build/", false, "build", true, true},
+		{"build/", false, "build", false, false},
+		{"build/", false, "x/build/a.ts", false, true},
+		// Character classes, negated with !.
+		{"v[0-9].ts", false, "v1.ts", false, true},
+		{"v[!0-9].ts", false, "v1.ts", false, false},
+		{"v?.ts", false, "vx.ts", false, true},
+	}
+	for _, tt := range tests {
+		rule, err := compileGlob(tt.pattern, tt.anchored)
+		if err != nil {
+			t.Errorf("compileGlob(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := rule.matches(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q (anchored %v) matches %q (dir %v) = %v; want %v", tt.pattern, tt.anchored, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestGlobLastMatchWins(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		// want is the pattern that decides, or "" when none matches.
+		want string
+	}{
+		{[]string{"**/dist/**", "!packages/api/dist/**"}, "packages/api/dist/a.ts", "!packages/api/dist/**"},
+		{[]string{"**/dist/**", "!packages/api/dist/**"}, "packages/web/dist/a.ts", "**/dist/**"},
+		// A later rule overrides the negation again.
+		{[]string{"**/dist/**", "!packages/api/dist/**", "**/*.draft.monkko.ts"}, "packages/api/dist/a.draft.monkko.ts", "**/*.draft.monkko.ts"},
+		{[]string{"!src/keep.ts", "src/**"}, "src/keep.ts", "src/**"},
+		{[]string{"**/dist/**"}, "src/a.ts", ""},
+	}
+	for _, tt := range tests {
+		rules, err := compileGlobs("excludes", tt.patterns, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if rule := lastMatch(rules, tt.path, false); rule != nil {
+			got = rule.Pattern
+		}
+		if got != tt.want {
+			t.Errorf("lastMatch(%q, %q) = %q; want %q", tt.patterns, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGlobCouldMatchBeneath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{"src/**/models/*.ts", "src", true},
+		{"src/**/models/*.ts", "src/a/b", true},
+		{"src/**/models/*.ts", "lib", false},
+		{"packages/*/schemas", "packages/api", true},
+		{"packages/*/schemas", "apps/web", false},
+		{"packages/*/schemas", "packages/api/schemas/nested", true},
+	}
+	for _, tt := range tests {
+		rule, err := compileGlob(tt.pattern, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rule.couldMatchBeneath(tt.dir); got != tt.want {
+			t.Errorf("%q couldMatchBeneath(%q) = %v; want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestCompileGlobsRejectsAbsolutePaths(t *testing.T) {
+	for _, pattern := range []string{"/abs/path/schemas/**", "!/abs/path/legacy/**"} {
+		_, err := compileGlobs("includes", []string{"src/**", pattern}, true)
+		if err == nil || !strings.Contains(err.Error(), pattern) {
+			t.Errorf("compileGlobs(%q) error = %v; want one naming the pattern", pattern, err)
+		}
+	}
+	if _, err := compileGlobs("includes", []string{"src/**", "!src/legacy/**"}, true); err != nil {
+		t.Errorf("relative patterns: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// monkkoIgnoreName is an ignore file read only by Monkko, for excluding
+// paths from discovery without touching .gitignore.
+const monkkoIgnoreName = ".monkkoignore"
+
+// ignoreFile is the rules of one ignore file, which apply to paths under dir.
+type ignoreFile struct {
+	dir string
+	// source is the file's path, for messages.
+	source string
+	rules  []globRule
+}
+
+// ignoreMatcher applies ignore files the way ripgrep does: .gitignore files
+// inside a git repository, plus its .git/info/exclude, and .monkkoignore
+// files anywhere. The files of a directory apply to everything beneath it,
+// deeper files take precedence over shallower ones and .monkkoignore over
+// .gitignore; within that order the last matching rule wins.
+type ignoreMatcher struct {
+	// gitRoot is the absolute path of the enclosing repository, if any.
+	gitRoot string
+	// loaded caches ignore files by absolute path.
+	loaded map[string][]ignoreFile
+}
+
+// newIgnoreMatcher finds the git repository enclosing the working directory.
+func newIgnoreMatcher() (*ignoreMatcher, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	m := &ignoreMatcher{loaded: make(map[string][]ignoreFile)}
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		// .git is a directory, or a file in worktrees and submodules.
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			m.gitRoot = dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return m, nil
+}
+
+// ignored returns the ignore rule, and the file it came from, that excludes
+// path (relative to the working directory), or nil when nothing does.
+func (m *ignoreMatcher) ignored(path string, isDir bool) (*globRule, string, error) {
+	abs, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return nil, "", err
+	}
+	files, err := m.filesFor(filepath.Dir(abs))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var match *globRule
+	var source string
+	for _, file := range files {
+		rel, err := filepath.Rel(file.dir, abs)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rule := lastMatch(file.rules, filepath.ToSlash(rel), isDir); rule != nil {
+			match, source = rule, file.source
+		}
+	}
+	if match == nil || match.negate {
+		return nil, "", nil
+	}
+	return match, source, nil
+}
+
+// filesFor returns the ignore files that apply to entries of dir, from the
+// lowest precedence to the highest.
+func (m *ignoreMatcher) filesFor(dir string) ([]ignoreFile, error) {
+	var chain []string
+	for d := dir; ; d = filepath.Dir(d) {
+		chain = append(chain, d)
+		if d == m.gitRoot || filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	var files []ignoreFile
+	if m.gitRoot != "" {
+		exclude, err := m.load(m.gitRoot, filepath.Join(m.gitRoot, ".git", "info", "exclude"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, exclude...)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		d := chain[i]
+		if m.gitRoot != "" && inDir(m.gitRoot, d) {
+			gitignore, err := m.load(d, filepath.Join(d, ".gitignore"))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, gitignore...)
+		}
+		monkkoignore, err := m.load(d, filepath.Join(d, monkkoIgnoreName))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, monkkoignore...)
+	}
+	return files, nil
+}
+
+// load reads the ignore file at path, whose rules are relative to dir. A
+// missing file yields nothing.
+func (m *ignoreMatcher) load(dir, path string) ([]ignoreFile, error) {
+	if files, ok := m.loaded[path]; ok {
+		return files, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// ENOTDIR: .git is a file in worktrees, so it has no info/exclude.
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			m.loaded[path] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	file := ignoreFile{dir: dir, source: displayPath(path)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Ignore files anchor only patterns containing a slash, as git does.
+		rule, err := compileGlob(line, false)
+		if err != nil {
+			// git skips patterns it cannot parse, and so do we.
+			continue
+		}
+		file.rules = append(file.rules, rule)
+	}
+
+	files := []ignoreFile{file}
+	m.loaded[path] = files
+	return files, nil
+}
+
+// inDir reports whether path is dir or inside it.
+func inDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,66 @@
+package generate
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDiscoveryHonoursIgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/info/exclude":  "scratch/\n",
+		".gitignore":         "*.draft.monkko.ts\n",
+		".monkkoignore":      "!shared.draft.monkko.ts\n",
+		"schemas/.gitignore": "!keep.draft.monkko.ts\n",
+
+		"schemas/user.monkko.ts":         "",
+		"schemas/post.draft.monkko.ts":   "",
+		"schemas/keep.draft.monkko.ts":   "",
+		"schemas/shared.draft.monkko.ts": "",
+		"scratch/tmp.monkko.ts":          "",
+	})
+	// Discovery works on paths relative to the working directory.
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	decisions, err := DiscoverSchemaFiles(&Config{UseIgnoreFiles: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]FileDecision)
+	for _, decision := range decisions {
+		got[decision.Path] = decision
+	}
+	tests := []struct {
+		path     string
+		included bool
+		rule     string
+	}{
+		{"schemas/user.monkko.ts", true, ""},
+		{"schemas/post.draft.monkko.ts", false, ".gitignore: *.draft.monkko.ts"},
+		// A deeper ignore file overrides a shallower one...
+		{"schemas/keep.draft.monkko.ts", true, ""},
+		// ...and .monkkoignore overrides .gitignore in the same directory.
+		{"schemas/shared.draft.monkko.ts", true, ""},
+		{"scratch", false, ".git/info/exclude: scratch/"},
+	}
+	for _, tt := range tests {
+		decision, ok := got[tt.path]
+		if !ok {
+			t.Errorf("%s: no decision", tt.path)
+			continue
+		}
+		if decision.Included != tt.included || decision.Rule != tt.rule {
+			t.Errorf("%s: included %v by %q; want %v by %q", tt.path, decision.Included, decision.Rule, tt.included, tt.rule)
+		}
+	}
+	if len(decisions) != len(tests) {
+		t.Errorf("got %d decisions; want %d: %+v", len(decisions), len(tests), decisions)
+	}
+}
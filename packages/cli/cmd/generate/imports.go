@@ -0,0 +1,130 @@
+package generate
+
+import (
+	"github.com/dop251/goja/ast"
+)
+
+// monkkoModules are the import paths whose exports construct schemas and fields.
+var monkkoModules = map[string]bool{
+	"@monkko/orm":         true,
+	"@monkko/orm/schemas": true,
+}
+
+// importBinding is a local name bound to a Monkko module, either the whole
+// module (`import * as orm`, or esbuild's `import_orm` namespace) or a single
+// export (`const { defineSchema: schema } = require(...)`).
+type importBinding struct {
+	namespace bool
+	export    string
+}
+
+// recordImports builds the import table from the top-level `require` calls of
+// the bundle, which is how esbuild emits every import of an external package.
+func (s *parseScope) recordImports(program *ast.Program) {
+	for _, stmt := range program.Body {
+		var bindings []*ast.Binding
+		switch decl := stmt.(type) {
+		case *ast.VariableStatement:
+			bindings = decl.List
+		case *ast.LexicalDeclaration:
+			bindings = decl.List
+		default:
+			continue
+		}
+
+		for _, binding := range bindings {
+			path, ok := requirePath(binding.Initializer)
+			if !ok || !monkkoModules[path] {
+				continue
+			}
+			switch target := binding.Target.(type) {
+			case *ast.Identifier:
+				s.imports[target.Name.String()] = importBinding{namespace: true}
+			case *ast.ObjectPattern:
+				for _, propNode := range target.Properties {
+					switch prop := propNode.(type) {
+					case *ast.PropertyShort:
+						name := prop.Name.Name.String()
+						s.imports[name] = importBinding{export: name}
+					case *ast.PropertyKeyed:
+						key, err := getKeyFromPropertyKeyed(prop)
+						if err != nil {
+							continue
+						}
+						if local, ok := prop.Value.(*ast.Identifier); ok {
+							s.imports[local.Name.String()] = importBinding{export: key}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// requirePath returns the module path of `require("x")`, also when esbuild
+// wraps it as `__toESM(require("x"))` for namespace imports.
+func requirePath(expr ast.Expression) (string, bool) {
+	call, ok := expr.(*ast.CallExpression)
+	if !ok || len(call.ArgumentList) == 0 {
+		return "", false
+	}
+	callee, ok := call.Callee.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	switch callee.Name.String() {
+	case "require":
+		path, ok := call.ArgumentList[0].(*ast.StringLiteral)
+		if !ok {
+			return "", false
+		}
+		return path.Value.String(), true
+	case "__toESM":
+		return requirePath(call.ArgumentList[0])
+	}
+	return "", false
+}
+
+// resolveExport reports which Monkko export expr refers to. It handles named
+// imports (`defineSchema`, or an alias of it), namespace members
+// (`import_orm.defineSchema`) and esbuild's `(0, import_orm.defineSchema)`.
+func (s *parseScope) resolveExport(expr ast.Expression) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		binding, ok := s.imports[e.Name.String()]
+		if ok && !binding.namespace {
+			return binding.export, true
+		}
+	case *ast.DotExpression:
+		if ns, ok := e.Left.(*ast.Identifier); ok {
+			if binding, ok := s.imports[ns.Name.String()]; ok && binding.namespace {
+				return e.Identifier.Name.String(), true
+			}
+		}
+	case *ast.SequenceExpression:
+		if len(e.Sequence) > 0 {
+			// The value of a sequence expression is its last expression.
+			return s.resolveExport(e.Sequence[len(e.Sequence)-1])
+		}
+	}
+	return "", false
+}
+
+// defineCallee returns "defineSchema" or "defineSubDocument" when callee is
+// bound to that Monkko export, and "" otherwise.
+func (s *parseScope) defineCallee(callee ast.Expression) string {
+	export, ok := s.resolveExport(callee)
+	if ok && (export == "defineSchema" || export == "defineSubDocument") {
+		return export
+	}
+	return ""
+}
+
+// fieldConstructor returns the field type of a `fields.<type>` callee, where
+// `fields` must be the Monkko export (directly, aliased, or via a namespace).
+func (s *parseScope) fieldConstructor(callee *ast.DotExpression) (string, bool) {
+	if export, ok := s.resolveExport(callee.Left); ok && export == "fields" {
+		return callee.Identifier.Name.String(), true
+	}
+	return "", false
+}
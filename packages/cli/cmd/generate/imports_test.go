@@ -0,0 +1,50 @@
+package generate
+
+import "testing"
+
+func TestImportForms(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"aliased", `import { defineSchema as schema, fields as f } from "@monkko/orm/schemas";
+
+export const User = schema({ db: "app", fields: { email: f.string({ required: true }) } });
+`},
+		{"namespace", `import * as orm from "@monkko/orm";
+
+export const User = orm.defineSchema({ db: "app", fields: { email: orm.fields.string({ required: true }) } });
+`},
+	}
+	for _, tt := range tests {
+		schemas, diags := parseFixture(t, map[string]string{"user.monkko.ts": tt.source}, "user.monkko.ts")
+		if diags.HasErrors() || len(schemas) != 1 {
+			t.Errorf("%s: parsed %d schema(s): %v", tt.name, len(schemas), diags)
+			continue
+		}
+		if email := findField(t, schemas[0], "email"); email.Type != "string" || !email.Required {
+			t.Errorf("%s: email = %+v; want a required string", tt.name, email)
+		}
+	}
+}
+
+func TestFieldsFromOtherModulesAreRejected(t *testing.T) {
+	_, diags := parseFixture(t, map[string]string{
+		"utils.ts": `export const trim = (options: object) => options;
+`,
+		"user.monkko.ts": `import { defineSchema, fields } from "@monkko/orm/schemas";
+import * as utils from "./utils";
+
+export const User = defineSchema({
+  db: "app",
+  fields: {
+    email: fields.string(),
+    name: utils.trim({ required: true }),
+  },
+});
+`}, "user.monkko.ts")
+	// Only calls on the Monkko `fields` export construct fields.
+	if len(diags) != 1 || diags[0].Code != CodeUnknownFieldType || diags[0].Line != 8 {
+		t.Errorf("got %v; want one %s error on line 8", diags, CodeUnknownFieldType)
+	}
+}
@@ -0,0 +1,55 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var LsFilesCmd = &cobra.Command{
+	Use:   "ls-files",
+	Short: "List the schema files generate would use",
+	Long: `Lists every .monkko.ts file found while walking the includes, marked "+" when it is used and "-" when it is not, with the rule that decided.
+Excluded directories are listed instead of the files inside them.`,
+	Args:         cobra.NoArgs,
+	RunE:         runLsFiles,
+	SilenceUsage: true,
+}
+
+func init() {
+	LsFilesCmd.Flags().BoolVar(&debugFlag, "debug", false, "Enable debug output")
+}
+
+func runLsFiles(cmd *cobra.Command, args []string) error {
+	config, err := LoadConfig(debugFlag)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	decisions, err := DiscoverSchemaFiles(config, debugFlag)
+	if err != nil {
+		return fmt.Errorf("failed to find schema files: %w", err)
+	}
+
+	included, skipped, skippedDirs := 0, 0, 0
+	for _, decision := range decisions {
+		mark, path := "-", decision.Path
+		switch {
+		case decision.Dir:
+			path += "/"
+			skippedDirs++
+		case decision.Included:
+			mark = "+"
+			included++
+		default:
+			skipped++
+		}
+		if decision.Rule == "" {
+			fmt.Printf("%s %s\n", mark, path)
+		} else {
+			fmt.Printf("%s %s (%s)\n", mark, path, decision.Rule)
+		}
+	}
+	fmt.Printf("%d schema file(s) included, %d skipped, %d director(ies) skipped\n", included, skipped, skippedDirs)
+	return nil
+}
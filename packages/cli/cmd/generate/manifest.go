@@ -0,0 +1,191 @@
+package generate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// manifestName is the file in each output directory that lists what the last
+// run generated there. Only files listed in it are ever deleted.
+const manifestName = ".monkko-manifest.json"
+
+const manifestVersion = 1
+
+// Manifest records every file generated into an output directory, keyed by
+// slash-separated path relative to the directory.
+type Manifest struct {
+	Version int                      `json:"version"`
+	Files   map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is a generated file: a hash of the content that was written
+// and the target that wrote it. Several targets can share a directory, and a
+// run only prunes the files of the targets it generated.
+type ManifestEntry struct {
+	Hash   string `json:"hash"`
+	Target string `json:"target"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{Version: manifestVersion, Files: make(map[string]ManifestEntry)}
+}
+
+// readManifest loads the manifest of dir. A directory without one yields nil
+// and no error.
+func readManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", manifestName, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported %s version %d", manifestName, m.Version)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]ManifestEntry)
+	}
+	return m, nil
+}
+
+// write saves the manifest to dir, or removes it when it lists no files.
+func (m *Manifest) write(dir string) error {
+	path := filepath.Join(dir, manifestName)
+	if len(m.Files) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	return writeFileAtomic(path, data)
+}
+
+// lookup returns the entry recorded for path. It is safe on a nil manifest.
+func (m *Manifest) lookup(path string) (ManifestEntry, bool) {
+	if m == nil {
+		return ManifestEntry{}, false
+	}
+	entry, ok := m.Files[path]
+	return entry, ok
+}
+
+// sortedPaths returns the manifest's paths in a stable order.
+func (m *Manifest) sortedPaths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for path := range m.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// contentHash identifies generated content in a manifest.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// fileHash hashes the current content of path; ok is false when the file
+// does not exist.
+func fileHash(path string) (hash string, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return contentHash(data), true, nil
+}
+
+// removeGenerated deletes a file listed in the manifest of dir, unless it
+// was edited since it was generated, then removes directories the deletion
+// left empty. kept reports whether the file is still there.
+func removeGenerated(dir, path, hash string) (kept bool, diags Diagnostics) {
+	filename := filepath.Join(dir, filepath.FromSlash(path))
+	current, exists, err := fileHash(filename)
+	if err != nil {
+		return true, Diagnostics{newError(filename, CodeReadFailed, "failed to read generated file: %v", err)}
+	}
+	if !exists {
+		return false, nil
+	}
+	if current != hash {
+		d := newError(filename, CodeEditedOutput, "not deleting %s: it was edited after it was generated", path)
+		d.Severity = SeverityWarning
+		return true, Diagnostics{d}
+	}
+	if err := os.Remove(filename); err != nil {
+		return true, Diagnostics{newError(filename, CodeWriteFailed, "failed to delete stale file: %v", err)}
+	}
+	removeEmptyDirs(dir, filepath.Dir(filename))
+	return false, nil
+}
+
+// removeEmptyDirs removes from and its parents while they are empty, up to
+// but not including root.
+func removeEmptyDirs(root, from string) {
+	root = filepath.Clean(root)
+	for dir := filepath.Clean(from); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// CleanOutputDir deletes every file the manifest of dir lists, keeping ones
+// edited by hand, and then the manifest itself.
+func CleanOutputDir(dir string, debug bool) (removed int, diags Diagnostics) {
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return 0, Diagnostics{newError(filepath.Join(dir, manifestName), CodeReadFailed, "%v", err)}
+	}
+	if manifest == nil {
+		return 0, nil
+	}
+
+	remaining := newManifest()
+	for _, path := range manifest.sortedPaths() {
+		entry := manifest.Files[path]
+		kept, removeDiags := removeGenerated(dir, path, entry.Hash)
+		diags = append(diags, removeDiags...)
+		if kept {
+			remaining.Files[path] = entry
+			continue
+		}
+		removed++
+		if debug {
+			fmt.Printf("  🗑️  %s\n", filepath.Join(dir, filepath.FromSlash(path)))
+		}
+	}
+
+	if err := remaining.write(dir); err != nil {
+		diags = append(diags, newError(filepath.Join(dir, manifestName), CodeWriteFailed, "failed to write manifest: %v", err))
+	}
+	if len(remaining.Files) == 0 {
+		// Only succeeds when nothing but generated files lived in dir.
+		os.Remove(dir)
+	}
+	return removed, diags
+}
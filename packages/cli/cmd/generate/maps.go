@@ -2,9 +2,42 @@ package generate
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// Converted value maps carry metadata next to the properties written in the
+// schema: a field's JSDoc, the source position of each object and its keys,
+// and the subdocument a field was built from. The NUL prefix cannot appear in
+// a schema's own keys, unlike `$`, which validation reports as illegal.
+const (
+	docKey         = "\x00doc"
+	posKey         = "\x00pos"
+	keysKey        = "\x00keys"
+	orderKey       = "\x00order"
+	subDocumentKey = "\x00subDocument"
+)
+
+func isMetaKey(key string) bool {
+	return strings.HasPrefix(key, "\x00")
+}
+
+// orderedKeys returns the keys of obj written in the schema, in declaration
+// order when the parser recorded it and sorted otherwise.
+func orderedKeys(obj map[string]interface{}) []string {
+	if order, ok := obj[orderKey].([]string); ok {
+		return order
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		if !isMetaKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // mapToSchema converts a map[string]interface{} from goja into our typed Schema struct.
 func mapToSchema(varName string, schemaMap map[string]interface{}, debug bool) (Schema, error) {
 	if debug {
@@ -14,8 +47,7 @@ func mapToSchema(varName string, schemaMap map[string]interface{}, debug bool) (
 	// Set default values
 	schema := Schema{
 		Name:       varName,
-		Collection: strings.ToLower(varName),
-		Fields:     make(map[string]Field),
+		Collection: defaultCollectionName(varName),
 	}
 
 	// Extract top-level properties
@@ -37,31 +69,8 @@ func mapToSchema(varName string, schemaMap map[string]interface{}, debug bool) (
 		if debug {
 			fmt.Println("......... Found fields object. Parsing fields...")
 		}
-		fields := make(map[string]Field)
-		for fieldName, fieldVal := range fieldsMap {
-			fieldObj, ok := fieldVal.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			field := Field{}
-			if fType, ok := fieldObj["type"].(string); ok {
-				field.Type = fType
-			}
-			if required, ok := fieldObj["required"].(bool); ok {
-				field.Required = required
-			}
-			if unique, ok := fieldObj["unique"].(bool); ok {
-				field.Unique = unique
-			}
-			if optional, ok := fieldObj["optional"].(bool); ok {
-				field.Optional = optional
-			}
-			if debug {
-				fmt.Printf("............... Found field: %s, Type: %s\n", fieldName, field.Type)
-			}
-			fields[fieldName] = field
-		}
-		schema.Fields = fields
+		schema.Fields = mapToFields(fieldsMap, debug)
+		schema.SubDocuments = collectSubDocuments(schema.Fields)
 	}
 
 	// Extract options
@@ -81,3 +90,184 @@ func mapToSchema(varName string, schemaMap map[string]interface{}, debug bool) (
 
 	return schema, nil
 }
+
+// defaultCollectionName is the collection of a schema that does not name one.
+func defaultCollectionName(schemaName string) string {
+	return strings.ToLower(schemaName)
+}
+
+// mapToFields converts a fields object from goja into typed Fields, in the
+// order they were declared.
+func mapToFields(fieldsMap map[string]interface{}, debug bool) []Field {
+	var fields []Field
+	for _, fieldName := range orderedKeys(fieldsMap) {
+		field, ok := mapToFieldValue(fieldsMap[fieldName], debug)
+		if !ok {
+			continue
+		}
+		if debug {
+			fmt.Printf("............... Found field: %s, Type: %s\n", fieldName, field.Type)
+		}
+		field.Name = fieldName
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// mapToFieldValue converts either a field definition object or the `[field]`
+// array shorthand. The shorthand lifts the item's required/optional flags onto
+// the array itself, since array elements cannot be individually optional.
+func mapToFieldValue(fieldVal interface{}, debug bool) (Field, bool) {
+	switch v := fieldVal.(type) {
+	case map[string]interface{}:
+		return mapToField(v, debug), true
+	case []interface{}:
+		if len(v) != 1 {
+			return Field{}, false
+		}
+		item, ok := mapToFieldValue(v[0], debug)
+		if !ok {
+			return Field{}, false
+		}
+		field := Field{
+			Type:        "array",
+			Description: item.Description,
+			Required:    item.Required,
+			Optional:    item.Optional,
+		}
+		item.Required, item.Optional, item.Description = false, false, ""
+		field.Items = &item
+		return field, true
+	}
+	return Field{}, false
+}
+
+// mapToField converts a single field definition, recursing into the
+// nested schema of object and subdocument fields and the items of arrays.
+func mapToField(fieldObj map[string]interface{}, debug bool) Field {
+	field := Field{}
+	if fType, ok := fieldObj["type"].(string); ok {
+		field.Type = fType
+	}
+	if doc, ok := fieldObj[docKey].(string); ok {
+		field.Description = doc
+	}
+	if required, ok := fieldObj["required"].(bool); ok {
+		field.Required = required
+	}
+	if unique, ok := fieldObj["unique"].(bool); ok {
+		field.Unique = unique
+	}
+	if optional, ok := fieldObj["optional"].(bool); ok {
+		field.Optional = optional
+	}
+	if subDocument, ok := fieldObj[subDocumentKey].(string); ok {
+		field.SubDocument = subDocument
+	}
+	if nested, ok := fieldObj["schema"].(map[string]interface{}); ok {
+		field.Fields = mapToFields(nested, debug)
+	}
+	if itemVal, ok := fieldObj["items"]; ok {
+		if item, ok := mapToFieldValue(itemVal, debug); ok {
+			item.Required, item.Optional = false, false
+			field.Items = &item
+		}
+	}
+	if minItems, ok := intValue(fieldObj["minItems"]); ok {
+		field.MinItems = &minItems
+	}
+	if maxItems, ok := intValue(fieldObj["maxItems"]); ok {
+		field.MaxItems = &maxItems
+	}
+	if minLength, ok := intValue(fieldObj["minLength"]); ok {
+		field.MinLength = &minLength
+	}
+	if maxLength, ok := intValue(fieldObj["maxLength"]); ok {
+		field.MaxLength = &maxLength
+	}
+	if pattern, ok := fieldObj["pattern"].(string); ok {
+		field.Pattern = pattern
+	}
+	if enumVals, ok := fieldObj["enum"].([]interface{}); ok {
+		for _, v := range enumVals {
+			if s, ok := v.(string); ok {
+				field.Enum = append(field.Enum, s)
+			}
+		}
+	}
+	if min, ok := floatValue(fieldObj["min"]); ok {
+		field.Min = &min
+	}
+	if max, ok := floatValue(fieldObj["max"]); ok {
+		field.Max = &max
+	}
+	if ref, ok := fieldObj["ref"].(string); ok {
+		field.Ref = ref
+	}
+	switch def := fieldObj["default"].(type) {
+	case string, bool:
+		field.Default = def
+	case int64, float64:
+		field.Default, _ = floatValue(def)
+	}
+	return field
+}
+
+// floatValue reads a number from a goja number literal.
+func floatValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// intValue reads an integer from a goja number literal, which may surface
+// as either int64 or float64.
+func intValue(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// collectSubDocuments returns every named subdocument reachable from fields,
+// ordered so that a subdocument always comes after the ones it embeds.
+func collectSubDocuments(fields []Field) []SubDocument {
+	var subDocuments []SubDocument
+	seen := make(map[string]bool)
+
+	var visit func(fields []Field)
+	var visitField func(field Field)
+	visit = func(fields []Field) {
+		for _, field := range fields {
+			visitField(field)
+		}
+	}
+	visitField = func(field Field) {
+		if field.Items != nil {
+			visitField(*field.Items)
+		}
+		if field.Fields == nil {
+			return
+		}
+		visit(field.Fields)
+		if field.SubDocument != "" && !seen[field.SubDocument] {
+			seen[field.SubDocument] = true
+			subDocuments = append(subDocuments, SubDocument{
+				Name:   field.SubDocument,
+				Fields: field.Fields,
+			})
+		}
+	}
+	visit(fields)
+
+	return subDocuments
+}
@@ -2,108 +2,109 @@ package generate
 
 import (
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/parser"
-	"github.com/evanw/esbuild/pkg/api"
+	"github.com/dop251/goja/unistring"
 )
 
-// ParseSchemaFiles iterates through files and extracts schemas.
-func ParseSchemaFiles(files []string, debug bool) ([]Schema, error) {
+// ParseSchemaFiles parses files on up to jobs workers and extracts schemas. A
+// file that fails does not stop the others; every problem is returned as a
+// Diagnostic. Files whose result is in cache, and unchanged since, are not
+// parsed again. Results keep the order of files whatever the scheduling.
+func ParseSchemaFiles(files []string, cache *Cache, jobs int, debug bool) ([]Schema, Diagnostics) {
 	if debug {
 		fmt.Println("🔎 Starting schema parsing...")
 	}
-	var allSchemas []Schema
 
-	for _, file := range files {
+	type fileResult struct {
+		schemas []Schema
+		diags   Diagnostics
+	}
+	results := make([]fileResult, len(files))
+	forEach(len(files), jobs, func(i int) {
+		file := files[i]
+		if schemas, fileDiags, ok := cache.load(file); ok {
+			if debug {
+				fmt.Printf("📦 Using cached result for %s\n", file)
+			}
+			results[i] = fileResult{schemas, fileDiags}
+			return
+		}
 		if debug {
 			fmt.Printf("📄 Parsing file: %s\n", file)
 		}
-		schemas, err := parseSchemaFile(file, debug)
-		if err != nil {
-			// Provide context for the error
-			return nil, fmt.Errorf("error parsing schemas from %s: %w", file, err)
-		}
-		allSchemas = append(allSchemas, schemas...)
+		schemas, inputs, fileDiags := parseSchemaFile(file, debug)
+		cache.store(file, inputs, schemas, fileDiags)
+		results[i] = fileResult{schemas, fileDiags}
+	})
+
+	var allSchemas []Schema
+	var diags Diagnostics
+	for _, result := range results {
+		allSchemas = append(allSchemas, result.schemas...)
+		diags = append(diags, result.diags...)
 	}
+	// A problem outside the schema files, such as in a shared tsconfig, is
+	// found again by every file that bundles it.
+	diags = diags.Unique()
 
 	if debug {
 		fmt.Println("✅ Finished schema parsing.")
 	}
-	return allSchemas, nil
+	return allSchemas, diags
 }
 
-// parseSchemaFile uses esbuild to transform TS to JS, then goja to parse and inspect the AST.
-func parseSchemaFile(filename string, debug bool) ([]Schema, error) {
-	sourceCode, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+// parseSchemaFile uses esbuild to bundle the TS file and its local imports into JS,
+// then goja to parse and inspect the AST. It also returns the files that went
+// into the bundle, which are empty when bundling failed.
+func parseSchemaFile(filename string, debug bool) ([]Schema, parseInputs, Diagnostics) {
+	// Step 1: Use esbuild's Build API to convert TypeScript to JavaScript, pulling in
+	// relative and tsconfig `paths` imports so shared subdocuments and constants resolve.
+	bundle, diags := bundleSchemaFile(filename)
+	if bundle == nil {
+		return nil, parseInputs{}, diags
 	}
 	if debug {
-		fmt.Printf("... Read %d bytes from %s\n", len(sourceCode), filename)
-	}
-
-	// Step 1: Use esbuild's Transform API to convert TypeScript to JavaScript
-	result := api.Transform(string(sourceCode), api.TransformOptions{
-		Sourcefile: filename,
-		Loader:     api.LoaderTS,
-		Format:     api.FormatCommonJS,
-	})
-
-	// esbuild's Go API panics on error, so we check the Errors slice.
-	if len(result.Errors) > 0 {
-		errorMessages := api.FormatMessages(result.Errors, api.FormatMessagesOptions{
-			Kind:  api.ErrorMessage,
-			Color: true,
-		})
-		// Each message is a formatted string, join them for a single print.
-		fmt.Fprintln(os.Stderr, "❌ Esbuild transform failed with errors:")
-		fmt.Fprint(os.Stderr, strings.Join(errorMessages, ""))
-		os.Exit(1)
+		fmt.Printf("... Bundled %s into %d bytes\n", filename, len(bundle.code))
 	}
 
-	jsCode := string(result.Code)
-
-	// fmt.Println(jsCode)
-
 	// Step 2: Parse the JavaScript code into an AST using goja's parser
-	program, err := parser.ParseFile(nil, "", jsCode, 0)
+	program, err := parser.ParseFile(nil, "", bundle.code, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse javascript: %w", err)
+		return nil, bundle.inputs, append(diags, syntaxDiagnostics(err, bundle)...)
 	}
 
 	// Step 3: Walk the AST to find 'defineSchema' calls
-	return findSchemasInAST(program, jsCode, debug)
+	schemas, schemaDiags := findSchemasInAST(program, bundle, debug)
+	return schemas, bundle.inputs, append(diags, schemaDiags...)
 }
 
-// getDefineSchemaCallee recursively traverses an expression to find the "defineSchema" identifier.
-// It handles simple identifiers, dot expressions (e.g., `orm.defineSchema`), and sequence
-// expressions (e.g., `(0, orm.defineSchema)`), which are common in transpiled code.
-func getDefineSchemaCallee(expr ast.Expression) *ast.Identifier {
-	switch e := expr.(type) {
-	case *ast.Identifier:
-		if e.Name.String() == "defineSchema" {
-			return e
-		}
-	case *ast.DotExpression:
-		if e.Identifier.Name.String() == "defineSchema" {
-			return &e.Identifier
-		}
-	case *ast.SequenceExpression:
-		if len(e.Sequence) > 0 {
-			// The value of a sequence expression is its last expression.
-			return getDefineSchemaCallee(e.Sequence[len(e.Sequence)-1])
+// syntaxDiagnostics maps goja parse errors back to the TypeScript source.
+func syntaxDiagnostics(err error, bundle *bundledFile) Diagnostics {
+	list, ok := err.(parser.ErrorList)
+	if !ok {
+		return Diagnostics{newError(displayPath(bundle.entry), CodeSyntaxError, "failed to parse javascript: %v", err)}
+	}
+
+	var diags Diagnostics
+	for _, parseErr := range list {
+		d := newError(displayPath(bundle.entry), CodeSyntaxError, "%s", parseErr.Message)
+		if source, line, column, ok := bundle.originalPosition(parseErr.Position.Line, parseErr.Position.Column-1); ok {
+			d.File = displayPath(source)
+			d.Line = line
+			d.Column = column + 1
 		}
+		diags = append(diags, d)
 	}
-	return nil
+	return diags
 }
 
 // processBindings abstracts the logic for finding defineSchema calls within
 // a list of variable bindings, which is common to both VariableStatement
 // and LexicalDeclaration.
-func processBindings(bindings []*ast.Binding, calleeFn func(ast.Expression) *ast.Identifier, processFn func(*ast.Identifier, *ast.Identifier, *ast.CallExpression) error, debug bool) error {
+func processBindings(bindings []*ast.Binding, calleeFn func(ast.Expression) string, processFn func(*ast.Identifier, string, *ast.CallExpression) error, debug bool) error {
 	for _, binding := range bindings {
 		if binding.Initializer == nil {
 			continue
@@ -115,14 +116,14 @@ func processBindings(bindings []*ast.Binding, calleeFn func(ast.Expression) *ast
 		}
 
 		callee := calleeFn(callExpr.Callee)
-		if callee == nil {
+		if callee == "" {
 			continue
 		}
 
 		if varName, ok := binding.Target.(*ast.Identifier); ok {
 			if debug {
 				fmt.Printf("... Analyzing call expression for variable: %s\n", varName.Name.String())
-				fmt.Printf("... Callee is: %s\n", callee.Name.String())
+				fmt.Printf("... Callee is: %s\n", callee)
 			}
 			if err := processFn(varName, callee, callExpr); err != nil {
 				return err
@@ -132,73 +133,227 @@ func processBindings(bindings []*ast.Binding, calleeFn func(ast.Expression) *ast
 	return nil
 }
 
-func findSchemasInAST(program *ast.Program, jsCode string, debug bool) ([]Schema, error) {
-	var schemas []Schema
+// parseScope holds the bindings of a bundle that field definitions can refer to.
+type parseScope struct {
+	bundle *bundledFile
+	// imports maps local names bound to @monkko/orm (see imports.go).
+	imports map[string]importBinding
+	// subDocuments maps a defineSubDocument binding to its converted fields object.
+	subDocuments map[string]map[string]interface{}
+	// constants maps other top-level bindings to their initializer, converted
+	// on demand when a field definition refers to them.
+	constants map[string]ast.Expression
+	// resolving guards against self-referencing constants.
+	resolving map[string]bool
+}
+
+func newParseScope(bundle *bundledFile) *parseScope {
+	return &parseScope{
+		bundle:       bundle,
+		imports:      make(map[string]importBinding),
+		subDocuments: make(map[string]map[string]interface{}),
+		constants:    make(map[string]ast.Expression),
+		resolving:    make(map[string]bool),
+	}
+}
+
+// errorAt returns an error Diagnostic at the original TypeScript location of node.
+func (s *parseScope) errorAt(node ast.Node, code, format string, args ...interface{}) error {
+	return s.bundle.diagnosticAt(node.Idx0(), SeverityError, code, fmt.Sprintf(format, args...))
+}
 
-	processNode := func(varName, callee *ast.Identifier, callExpr *ast.CallExpression) error {
-		if callee.Name.String() != "defineSchema" {
+// recordConstants remembers the initializer of every plain top-level binding.
+func recordConstants(bindings []*ast.Binding, scope *parseScope) {
+	for _, binding := range bindings {
+		if binding.Initializer == nil {
+			continue
+		}
+		if name, ok := binding.Target.(*ast.Identifier); ok {
+			scope.constants[name.Name.String()] = binding.Initializer
+		}
+	}
+}
+
+// findSchemasInAST walks a bundled program. Subdocuments and constants from every
+// bundled module are tracked, but only schemas written in the entry file itself are
+// returned; imported schema files produce their own output when they are parsed.
+func findSchemasInAST(program *ast.Program, bundle *bundledFile, debug bool) ([]Schema, Diagnostics) {
+	var schemas []Schema
+	var diags Diagnostics
+	scope := newParseScope(bundle)
+	exportNames := collectExportNames(program)
+	scope.recordImports(program)
+	inEntry := false
+
+	processSchema := func(varName *ast.Identifier, callee string, callExpr *ast.CallExpression) error {
+		if callee == "defineSubDocument" {
+			return processSubDocument(varName, callExpr, scope, debug)
+		}
+		if callee != "defineSchema" || !inEntry {
 			return nil
 		}
 
+		// The fallback schema name is the export name, so `export { User as Account }`
+		// yields Account and a default export is named after its file.
+		name := varName.Name.String()
+		if exported, ok := exportNames[name]; ok {
+			name = exported
+		}
+		if name == "default" {
+			name = defaultSchemaName(bundle.entry)
+		}
+
 		if debug {
-			fmt.Printf("... Found schema variable: %s\n", varName.Name.String())
+			fmt.Printf("... Found schema variable: %s\n", name)
 		}
 
 		if len(callExpr.ArgumentList) != 1 {
-			return fmt.Errorf("defineSchema expects exactly one argument for '%s'", varName.Name.String())
+			return scope.errorAt(callExpr, CodeInvalidDefinition, "defineSchema expects exactly one argument for '%s'", name)
 		}
 		schemaObjNode, ok := callExpr.ArgumentList[0].(*ast.ObjectLiteral)
 		if !ok {
-			return fmt.Errorf("expected schema definition to be an object literal for '%s'", varName.Name.String())
+			return scope.errorAt(callExpr.ArgumentList[0], CodeInvalidDefinition, "expected schema definition to be an object literal for '%s'", name)
 		}
 
 		// Convert AST object to map[string]interface{}
-		schemaMapInterface, err := convertASTNodeToValue(schemaObjNode)
+		schemaMapInterface, err := convertASTNodeToValue(schemaObjNode, scope)
 		if err != nil {
-			return fmt.Errorf("error converting schema AST to map for '%s': %w", varName.Name.String(), err)
+			return err
 		}
 
 		schemaMap, ok := schemaMapInterface.(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("internal error: converted schema AST is not a map for '%s'", varName.Name.String())
+			return fmt.Errorf("internal error: converted schema AST is not a map for '%s'", name)
+		}
+
+		// Definitions with errors are reported and skipped; warnings still
+		// let the schema through.
+		validation, references := validateSchemaMap(bundle, name, defaultCollectionName(name), schemaMap)
+		diags = append(diags, validation...)
+		if validation.HasErrors() {
+			return nil
 		}
 
 		// Use the existing mapToSchema function from maps.go
-		schema, err := mapToSchema(varName.Name.String(), schemaMap, debug)
+		schema, err := mapToSchema(name, schemaMap, debug)
 		if err != nil {
-			return fmt.Errorf("error mapping schema for '%s': %w", varName.Name.String(), err)
+			return fmt.Errorf("error mapping schema for '%s': %w", name, err)
+		}
+		schema.Description = bundle.leadingDoc(varName.Idx0())
+		schema.References = references
+		schema.File = displayPath(bundle.entry)
+		if source, line, column, ok := bundle.originalSource(position(schemaMap, "name")); ok {
+			schema.File, schema.Line, schema.Column = displayPath(source), line, column+1
 		}
 
 		schemas = append(schemas, schema)
 		return nil
 	}
 
+	// A broken definition is reported and skipped so the rest of the file
+	// still gets checked.
+	processNode := func(varName *ast.Identifier, callee string, callExpr *ast.CallExpression) error {
+		if err := processSchema(varName, callee, callExpr); err != nil {
+			diags = append(diags, asDiagnostic(err, displayPath(bundle.entry), CodeInvalidDefinition))
+		}
+		return nil
+	}
+
 	for _, stmt := range program.Body {
 		var err error
+		inEntry = bundle.fromEntry(stmt.Idx0())
 		if varStmt, ok := stmt.(*ast.VariableStatement); ok {
-			err = processBindings(varStmt.List, getDefineSchemaCallee, processNode, debug)
+			recordConstants(varStmt.List, scope)
+			err = processBindings(varStmt.List, scope.defineCallee, processNode, debug)
 		}
 		if lexDecl, ok := stmt.(*ast.LexicalDeclaration); ok {
-			err = processBindings(lexDecl.List, getDefineSchemaCallee, processNode, debug)
+			recordConstants(lexDecl.List, scope)
+			err = processBindings(lexDecl.List, scope.defineCallee, processNode, debug)
 		}
 		if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok {
 			if assignExpr, ok := exprStmt.Expression.(*ast.AssignExpression); ok {
-				if dotExpr, ok := assignExpr.Left.(*ast.DotExpression); ok {
-					if exports, ok := dotExpr.Left.(*ast.Identifier); ok && exports.Name.String() == "exports" {
-						if callExpr, ok := assignExpr.Right.(*ast.CallExpression); ok {
-							if callee := getDefineSchemaCallee(callExpr.Callee); callee != nil {
-								err = processNode(&dotExpr.Identifier, callee, callExpr)
-							}
-						}
-					}
+				if exportName, ok := exportTarget(assignExpr.Left); ok {
+					err = processExportAssignment(exportName, assignExpr.Right, scope.defineCallee, processNode)
 				}
 			}
 		}
 		if err != nil {
-			return nil, err
+			diags = append(diags, asDiagnostic(err, displayPath(bundle.entry), CodeInvalidDefinition))
+		}
+	}
+	return schemas, diags
+}
+
+// processExportAssignment handles hand-written CommonJS exports: `exports.X = defineSchema(...)`,
+// `module.exports = defineSchema(...)` and `module.exports = { X: defineSchema(...) }`.
+func processExportAssignment(exportName string, value ast.Expression, calleeFn func(ast.Expression) string, processFn func(*ast.Identifier, string, *ast.CallExpression) error) error {
+	switch v := value.(type) {
+	case *ast.CallExpression:
+		if callee := calleeFn(v.Callee); callee != "" {
+			return processFn(exportIdentifier(exportName, v), callee, v)
+		}
+	case *ast.ObjectLiteral:
+		if exportName != "default" {
+			return nil
 		}
+		for _, propNode := range v.Value {
+			prop, ok := propNode.(*ast.PropertyKeyed)
+			if !ok {
+				continue
+			}
+			callExpr, ok := prop.Value.(*ast.CallExpression)
+			if !ok {
+				continue
+			}
+			callee := calleeFn(callExpr.Callee)
+			if callee == "" {
+				continue
+			}
+			key, err := getKeyFromPropertyKeyed(prop)
+			if err != nil {
+				continue
+			}
+			if err := processFn(exportIdentifier(key, prop), callee, callExpr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportIdentifier stands in for the binding name of a schema that is only
+// reachable through an export.
+func exportIdentifier(name string, at ast.Node) *ast.Identifier {
+	return &ast.Identifier{Name: unistring.NewFromString(name), Idx: at.Idx0()}
+}
+
+// processSubDocument records a `defineSubDocument({...})` binding so that later
+// calls such as `Address({ optional: true })` resolve to its fields.
+func processSubDocument(varName *ast.Identifier, callExpr *ast.CallExpression, scope *parseScope, debug bool) error {
+	name := varName.Name.String()
+	if debug {
+		fmt.Printf("... Found subdocument variable: %s\n", name)
+	}
+
+	if len(callExpr.ArgumentList) != 1 {
+		return scope.errorAt(callExpr, CodeInvalidDefinition, "defineSubDocument expects exactly one argument for '%s'", name)
+	}
+	fieldsNode, ok := callExpr.ArgumentList[0].(*ast.ObjectLiteral)
+	if !ok {
+		return scope.errorAt(callExpr.ArgumentList[0], CodeInvalidDefinition, "expected subdocument definition to be an object literal for '%s'", name)
+	}
+
+	fieldsVal, err := convertASTNodeToValue(fieldsNode, scope)
+	if err != nil {
+		return err
 	}
-	return schemas, nil
+	fieldsMap, ok := fieldsVal.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("internal error: converted subdocument AST is not a map for '%s'", name)
+	}
+
+	scope.subDocuments[name] = fieldsMap
+	return nil
 }
 
 // getKeyFromPropertyKeyed extracts the string key from a property in an AST object literal.
@@ -214,62 +369,227 @@ func getKeyFromPropertyKeyed(prop *ast.PropertyKeyed) (string, error) {
 
 // convertASTNodeToValue recursively converts an AST expression node into a Go interface{}.
 // It handles literals, objects, and the special `fields.type()` call expressions
-// to build a map that can be passed to the `mapToSchema` function.
-func convertASTNodeToValue(node ast.Expression) (interface{}, error) {
+// to build a map that can be passed to the `mapToSchema` function. Identifiers,
+// template literals and arithmetic are folded to constants (see constants.go);
+// anything else is reported with its original source location.
+func convertASTNodeToValue(node ast.Expression, scope *parseScope) (interface{}, error) {
 	switch n := node.(type) {
 	case *ast.StringLiteral:
 		return n.Value.String(), nil
 	case *ast.NumberLiteral:
+		if num, ok := n.Value.(float64); ok {
+			return finiteNumber(num, n, scope)
+		}
 		return n.Value, nil
 	case *ast.BooleanLiteral:
 		return n.Value, nil
 	case *ast.NullLiteral:
 		return nil, nil
+	case *ast.Identifier:
+		// References to other top-level bindings, e.g. `enum: ROLES` with
+		// ROLES imported from a shared module.
+		name := n.Name.String()
+		init, ok := scope.constants[name]
+		if !ok {
+			return nil, scope.errorAt(n, CodeUnresolvedIdentifier, "cannot resolve identifier '%s' to a constant", name)
+		}
+		if scope.resolving[name] {
+			return nil, scope.errorAt(n, CodeUnresolvedIdentifier, "circular reference to '%s'", name)
+		}
+		scope.resolving[name] = true
+		defer delete(scope.resolving, name)
+		return convertASTNodeToValue(init, scope)
+	case *ast.ArrayLiteral:
+		// Arrays appear both as the `[fields.string()]` field shorthand and as
+		// plain values such as enum lists.
+		values := make([]interface{}, 0, len(n.Value))
+		for _, elem := range n.Value {
+			if elem == nil {
+				return nil, scope.errorAt(n, CodeUnsupportedSyntax, "array holes are not supported")
+			}
+			// `[...ROLES, "guest"]` splices in the elements of a constant array.
+			if spread, ok := elem.(*ast.SpreadElement); ok {
+				val, err := convertASTNodeToValue(spread.Expression, scope)
+				if err != nil {
+					return nil, err
+				}
+				items, ok := val.([]interface{})
+				if !ok {
+					return nil, scope.errorAt(spread, CodeUnsupportedSyntax, "spread element must resolve to an array")
+				}
+				values = append(values, items...)
+				continue
+			}
+			val, err := convertASTNodeToValue(elem, scope)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val)
+		}
+		return values, nil
+	case *ast.NewExpression:
+		// `new Date()` and `new Date("2024-01-01")` are accepted as date defaults.
+		if callee, ok := n.Callee.(*ast.Identifier); ok && callee.Name.String() == "Date" {
+			if len(n.ArgumentList) == 0 {
+				return dateNowDefault, nil
+			}
+			if len(n.ArgumentList) == 1 {
+				return convertASTNodeToValue(n.ArgumentList[0], scope)
+			}
+		}
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "unsupported new expression: only new Date(...) is allowed")
+	case *ast.ArrowFunctionLiteral:
+		// `() => new Date()` is the idiomatic "current time" default.
+		if body, ok := n.Body.(*ast.ExpressionBody); ok {
+			if val, err := convertASTNodeToValue(body.Expression, scope); err == nil && val == dateNowDefault {
+				return dateNowDefault, nil
+			}
+		}
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "unsupported function value: only () => new Date() is allowed")
 	case *ast.ObjectLiteral:
 		objMap := make(map[string]interface{})
+		// keyPositions lets validation point at the offending key; keyOrder
+		// keeps the declaration order, which Go maps lose.
+		keyPositions := make(map[string]file.Idx)
+		var keyOrder []string
+		set := func(key string, val interface{}, at file.Idx) {
+			// Like JS, a key that is set again keeps its original place.
+			if _, exists := objMap[key]; !exists {
+				keyOrder = append(keyOrder, key)
+			}
+			objMap[key] = val
+			keyPositions[key] = at
+		}
 		for _, propNode := range n.Value {
+			// Shorthand `{ ROLES }` reads the binding of the same name.
+			if short, ok := propNode.(*ast.PropertyShort); ok && short.Initializer == nil {
+				val, err := convertASTNodeToValue(&short.Name, scope)
+				if err != nil {
+					return nil, err
+				}
+				set(short.Name.Name.String(), val, short.Idx0())
+				continue
+			}
+			// `...auditFields` merges a resolvable object; like JS, keys that
+			// come later override earlier ones.
+			if spread, ok := propNode.(*ast.SpreadElement); ok {
+				val, err := convertASTNodeToValue(spread.Expression, scope)
+				if err != nil {
+					return nil, err
+				}
+				spreadMap, ok := val.(map[string]interface{})
+				if !ok {
+					return nil, scope.errorAt(spread, CodeUnsupportedSyntax, "spread element must resolve to an object")
+				}
+				spreadPositions, _ := spreadMap[keysKey].(map[string]file.Idx)
+				for _, key := range orderedKeys(spreadMap) {
+					at, ok := spreadPositions[key]
+					if !ok {
+						at = spread.Idx0()
+					}
+					set(key, spreadMap[key], at)
+				}
+				continue
+			}
 			prop, ok := propNode.(*ast.PropertyKeyed)
 			if !ok {
-				continue // Or handle other property types if needed
+				return nil, scope.errorAt(propNode, CodeUnsupportedSyntax, "unsupported object property: %s", describeNode(propNode))
+			}
+			if prop.Kind != ast.PropertyKindValue {
+				return nil, scope.errorAt(prop, CodeUnsupportedSyntax, "%s properties are not supported in schema definitions", prop.Kind)
+			}
+			if prop.Computed {
+				return nil, scope.errorAt(prop, CodeUnsupportedSyntax, "computed property keys are not supported in schema definitions")
 			}
 			key, err := getKeyFromPropertyKeyed(prop)
 			if err != nil {
-				return nil, err
+				return nil, scope.errorAt(prop, CodeUnsupportedSyntax, "%v", err)
 			}
-			val, err := convertASTNodeToValue(prop.Value)
+			val, err := convertASTNodeToValue(prop.Value, scope)
 			if err != nil {
 				return nil, err
 			}
-			objMap[key] = val
+			if doc := scope.bundle.leadingDoc(prop.Key.Idx0()); doc != "" {
+				attachDoc(val, doc)
+			}
+			set(key, val, prop.Key.Idx0())
 		}
+		objMap[posKey] = n.Idx0()
+		objMap[keysKey] = keyPositions
+		objMap[orderKey] = keyOrder
 		return objMap, nil
 	case *ast.CallExpression:
 		// Handle different types of call expressions
 		switch callee := n.Callee.(type) {
 		case *ast.DotExpression:
 			// This is for handling field definitions like `fields.string({ required: true })`
-			// The type is the identifier, e.g., "string" from "fields.string"
-			fieldType := callee.Identifier.Name.String()
+			// The type is the identifier, e.g., "string" from "fields.string". Only
+			// the `fields` export of @monkko/orm counts; `utils.trim()` is not a field.
+			fieldType, ok := scope.fieldConstructor(callee)
+			if !ok {
+				return nil, scope.errorAt(n, CodeUnsupportedSyntax, "'%s' is not a Monkko field constructor", describeCallee(callee))
+			}
 
-			var configMap map[string]interface{}
-			// The arguments to the call are the field configs
-			if len(n.ArgumentList) > 0 {
-				if argObj, ok := n.ArgumentList[0].(*ast.ObjectLiteral); ok {
-					val, err := convertASTNodeToValue(argObj)
-					if err != nil {
-						return nil, err
-					}
-					if val != nil {
-						configMap, _ = val.(map[string]interface{})
-					}
+			// `fields.object(schema, opts)` takes the nested schema first and the
+			// field settings second, unlike the scalar field types.
+			if fieldType == "object" {
+				if len(n.ArgumentList) == 0 {
+					return nil, scope.errorAt(n, CodeInvalidDefinition, "fields.object expects a schema argument")
+				}
+				schemaMap, err := convertObjectArgument(n.ArgumentList[0], scope)
+				if err != nil {
+					return nil, err
+				}
+				var optsArg ast.Expression
+				if len(n.ArgumentList) > 1 {
+					optsArg = n.ArgumentList[1]
+				}
+				configMap, err := convertObjectArgument(optsArg, scope)
+				if err != nil {
+					return nil, err
+				}
+				configMap["type"] = "object"
+				configMap["schema"] = schemaMap
+				configMap[posKey] = n.Idx0()
+				return configMap, nil
+			}
+
+			// `fields.array(item, opts)` likewise takes the item definition first.
+			if fieldType == "array" {
+				if len(n.ArgumentList) == 0 {
+					return nil, scope.errorAt(n, CodeInvalidDefinition, "fields.array expects an item argument")
+				}
+				item, err := convertASTNodeToValue(n.ArgumentList[0], scope)
+				if err != nil {
+					return nil, err
+				}
+				var optsArg ast.Expression
+				if len(n.ArgumentList) > 1 {
+					optsArg = n.ArgumentList[1]
+				}
+				configMap, err := convertObjectArgument(optsArg, scope)
+				if err != nil {
+					return nil, err
 				}
+				configMap["type"] = "array"
+				configMap["items"] = item
+				configMap[posKey] = n.Idx0()
+				return configMap, nil
 			}
-			if configMap == nil {
-				configMap = make(map[string]interface{})
+
+			// The first argument, if any, holds the field options.
+			var optsArg ast.Expression
+			if len(n.ArgumentList) > 0 {
+				optsArg = n.ArgumentList[0]
+			}
+			configMap, err := convertObjectArgument(optsArg, scope)
+			if err != nil {
+				return nil, err
 			}
 
 			// Inject the "type" property, which mapToSchema expects
 			configMap["type"] = fieldType
+			configMap[posKey] = n.Idx0()
 
 			return configMap, nil
 
@@ -277,32 +597,81 @@ func convertASTNodeToValue(node ast.Expression) (interface{}, error) {
 			// This is for handling subdocument references like `Address({ optional: true })`
 			subdocType := callee.Name.String()
 
-			var configMap map[string]interface{}
-			// The arguments to the call are the subdocument configs
+			// The first argument, if any, holds the subdocument options.
+			var optsArg ast.Expression
 			if len(n.ArgumentList) > 0 {
-				if argObj, ok := n.ArgumentList[0].(*ast.ObjectLiteral); ok {
-					val, err := convertASTNodeToValue(argObj)
-					if err != nil {
-						return nil, err
-					}
-					if val != nil {
-						configMap, _ = val.(map[string]interface{})
-					}
-				}
+				optsArg = n.ArgumentList[0]
 			}
-			if configMap == nil {
-				configMap = make(map[string]interface{})
+			configMap, err := convertObjectArgument(optsArg, scope)
+			if err != nil {
+				return nil, err
 			}
 
-			// Mark this as a subdocument type
-			configMap["type"] = subdocType
+			// Subdocuments defined in this bundle become object fields carrying
+			// their nested schema; any other function is not a field.
+			subdocFields, ok := scope.subDocuments[subdocType]
+			if !ok {
+				names := make([]string, 0, len(scope.subDocuments))
+				for name := range scope.subDocuments {
+					names = append(names, name)
+				}
+				return nil, scope.errorAt(n, CodeUnknownFieldType, "'%s' is not a subdocument defined with defineSubDocument%s", subdocType, didYouMean(subdocType, names))
+			}
+			configMap["type"] = "object"
+			configMap[subDocumentKey] = subdocType
+			configMap["schema"] = subdocFields
+			configMap[posKey] = n.Idx0()
 
 			return configMap, nil
 
 		default:
-			return nil, fmt.Errorf("unsupported call expression callee type: %T", n.Callee)
+			return nil, scope.errorAt(n, CodeUnsupportedSyntax, "unsupported call expression callee type: %T", n.Callee)
+		}
+	case *ast.TemplateLiteral:
+		return foldTemplateLiteral(n, scope)
+	case *ast.UnaryExpression:
+		return foldUnaryExpression(n, scope)
+	case *ast.BinaryExpression:
+		return foldBinaryExpression(n, scope)
+	default:
+		return nil, scope.errorAt(n, CodeUnsupportedSyntax, "%s cannot be evaluated at generation time", describeNode(n))
+	}
+}
+
+// attachDoc records a JSDoc comment on a field definition value, including the
+// item of a `[field]` array shorthand.
+func attachDoc(val interface{}, doc string) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		v[docKey] = doc
+	case []interface{}:
+		if len(v) == 1 {
+			attachDoc(v[0], doc)
 		}
+	}
+}
+
+// convertObjectArgument converts an optional options argument, an object
+// literal or a constant holding one, into a map. A missing argument yields an
+// empty map.
+func convertObjectArgument(arg ast.Expression, scope *parseScope) (map[string]interface{}, error) {
+	if arg == nil {
+		return make(map[string]interface{}), nil
+	}
+	// A constant such as `const opts = { required: true }` is resolved
+	// like any other value; anything else cannot be evaluated here.
+	switch arg.(type) {
+	case *ast.ObjectLiteral, *ast.Identifier:
 	default:
-		return nil, fmt.Errorf("unsupported AST node type for conversion: %T", n)
+		return nil, scope.errorAt(arg, CodeUnsupportedSyntax, "options must be an object literal or a constant, got %s", describeNode(arg))
+	}
+	val, err := convertASTNodeToValue(arg, scope)
+	if err != nil {
+		return nil, err
+	}
+	configMap, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, scope.errorAt(arg, CodeInvalidDefinition, "options must be an object, got %s", describeValue(val))
 	}
+	return configMap, nil
 }
@@ -0,0 +1,159 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseFixture writes files to a temporary directory and parses the entry
+// schema file among them.
+func parseFixture(t *testing.T, files map[string]string, entry string) ([]Schema, Diagnostics) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	return ParseSchemaFiles([]string{filepath.Join(dir, filepath.FromSlash(entry))}, nil, 1, false)
+}
+
+// findField returns the top-level field called name.
+func findField(t *testing.T, schema Schema, name string) Field {
+	t.Helper()
+	for _, field := range schema.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	t.Fatalf("schema %s has no field %q", schema.Name, name)
+	return Field{}
+}
+
+func TestOptionsFromConstants(t *testing.T) {
+	schemas, diags := parseFixture(t, map[string]string{"user.monkko.ts": `import { defineSchema, defineSubDocument, fields } from "@monkko/orm/schemas";
+
+const nameOptions = { required: true, maxLength: 5 };
+const addressOptions = { required: true };
+
+const Address = defineSubDocument({ street: fields.string() });
+
+export const User = defineSchema({
+  name: "User",
+  db: "app",
+  collection: "users",
+  fields: {
+    name: fields.string(nameOptions),
+    address: Address(addressOptions),
+  },
+});
+`}, "user.monkko.ts")
+	if diags.HasErrors() || len(schemas) != 1 {
+		t.Fatalf("parsed %d schema(s): %v", len(schemas), diags)
+	}
+	name := findField(t, schemas[0], "name")
+	if !name.Required || name.MaxLength == nil || *name.MaxLength != 5 {
+		t.Errorf("name = %+v; want required with maxLength 5", name)
+	}
+	if address := findField(t, schemas[0], "address"); !address.Required || address.SubDocument != "Address" {
+		t.Errorf("address = %+v; want a required Address", address)
+	}
+}
+
+func TestUnfoldableOptionsAreReported(t *testing.T) {
+	tests := []struct {
+		field string
+		code  string
+	}{
+		{"fields.number(Math.max(1, 2))", CodeUnsupportedSyntax},
+		{"Address(makeOptions())", CodeUnsupportedSyntax},
+		{"fields.string(LIMIT)", CodeInvalidDefinition},
+	}
+	for _, tt := range tests {
+		_, diags := parseFixture(t, map[string]string{"user.monkko.ts": `import { defineSchema, defineSubDocument, fields } from "@monkko/orm/schemas";
+
+const LIMIT = 5;
+const makeOptions = () => ({ required: true });
+const Address = defineSubDocument({ street: fields.string() });
+
+export const User = defineSchema({
+  name: "User",
+  db: "app",
+  collection: "users",
+  fields: {
+    value: ` + tt.field + `,
+  },
+});
+`}, "user.monkko.ts")
+		if len(diags) != 1 || diags[0].Code != tt.code || diags[0].Line != 12 {
+			t.Errorf("%s: got %v; want one %s error on line 12", tt.field, diags, tt.code)
+		}
+	}
+}
+
+func TestSpreadKeysKeepTheirPosition(t *testing.T) {
+	schemas, diags := parseFixture(t, map[string]string{"user.monkko.ts": `import { defineSchema, fields } from "@monkko/orm/schemas";
+
+const auditFields = { createdBy: fields.string(), updatedBy: fields.string() };
+
+export const User = defineSchema({
+  db: "app",
+  fields: {
+    ...auditFields,
+    name: fields.string(),
+    createdBy: fields.string({ required: true }),
+  },
+});
+`}, "user.monkko.ts")
+	if diags.HasErrors() || len(schemas) != 1 {
+		t.Fatalf("parsed %d schema(s): %v", len(schemas), diags)
+	}
+	var names []string
+	for _, field := range schemas[0].Fields {
+		names = append(names, field.Name)
+	}
+	// Like JS, the later createdBy wins but keeps the spread's position.
+	if want := []string{"createdBy", "updatedBy", "name"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("fields = %v; want %v", names, want)
+	}
+	if !findField(t, schemas[0], "createdBy").Required {
+		t.Error("createdBy kept the spread's options")
+	}
+}
+
+func TestUnresolvableSpreadIsReported(t *testing.T) {
+	for _, spread := range []string{"...LIMIT", "...sharedFields"} {
+		_, diags := parseFixture(t, map[string]string{"user.monkko.ts": `import { defineSchema, fields } from "@monkko/orm/schemas";
+import { sharedFields } from "some-package";
+
+const LIMIT = 5;
+
+export const User = defineSchema({
+  db: "app",
+  fields: {
+    ` + spread + `,
+  },
+});
+`}, "user.monkko.ts")
+		if len(diags) != 1 || diags[0].Code != CodeUnsupportedSyntax || diags[0].Line != 9 {
+			t.Errorf("%s: got %v; want one %s error on line 9", spread, diags, CodeUnsupportedSyntax)
+		}
+	}
+}
+
+func TestSharedBuildWarningIsReportedOnce(t *testing.T) {
+	dir := t.TempDir()
+	schema := `import { defineSchema, fields } from "@monkko/orm/schemas";
+
+export const %s = defineSchema({ db: "app", collection: "items", fields: { name: fields.string() } });
+`
+	writeFiles(t, dir, map[string]string{
+		"tsconfig.json":  `{ "extends": "./missing.json" }`,
+		"user.monkko.ts": fmt.Sprintf(schema, "User"),
+		"post.monkko.ts": fmt.Sprintf(schema, "Post"),
+	})
+	files := []string{filepath.Join(dir, "user.monkko.ts"), filepath.Join(dir, "post.monkko.ts")}
+	_, diags := ParseSchemaFiles(files, nil, 1, false)
+	if len(diags) != 1 || diags[0].Code != CodeBuildWarning || !strings.Contains(diags[0].Message, "[tsconfig.json]") {
+		t.Errorf("got %v; want one %s naming esbuild's tsconfig.json check", diags, CodeBuildWarning)
+	}
+}
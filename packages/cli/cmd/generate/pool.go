@@ -0,0 +1,46 @@
+package generate
+
+import (
+	"runtime"
+	"sync"
+)
+
+// defaultJobs is the number of workers used when --jobs is not given.
+func defaultJobs() int {
+	return runtime.NumCPU()
+}
+
+// forEach calls fn for every index below n on at most jobs goroutines and
+// waits for them to finish. Callers store results by index, so output does
+// not depend on which worker finished first.
+func forEach(n, jobs int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = defaultJobs()
+	}
+	if jobs > n {
+		jobs = n
+	}
+	if jobs <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
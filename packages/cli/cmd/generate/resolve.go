@@ -0,0 +1,259 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveExtensions are tried, in esbuild's default order, on an import
+// written without an extension.
+var resolveExtensions = []string{".tsx", ".ts", ".jsx", ".js", ".css", ".json"}
+
+// bundleImport is an import found in a bundled file, as the esbuild
+// metafile reports it.
+type bundleImport struct {
+	// importer is the absolute path of the file with the import.
+	importer string
+	// specifier is the import as written, such as `./shared` or `@/lib/x`.
+	specifier string
+}
+
+// resolutionInputs returns what the resolution of imports depends on besides
+// the bundled files: the tsconfig files read for their `paths`, and the files
+// that do not exist but would take over an import if they were created, such
+// as `shared.tsx` next to the `shared.ts` that `./shared` found, or a `paths`
+// target that is missing, leaving the import external.
+func resolutionInputs(imports []bundleImport) (configs, absent []string) {
+	tsconfigs := make(map[string]*tsconfigPaths)
+	seen := make(map[string]bool)
+	for _, imp := range imports {
+		var bases []string
+		if isRelativeSpecifier(imp.specifier) {
+			bases = []string{filepath.Join(filepath.Dir(imp.importer), filepath.FromSlash(imp.specifier))}
+		} else {
+			tsconfig := nearestTsconfig(filepath.Dir(imp.importer))
+			if tsconfig == "" {
+				continue
+			}
+			paths, ok := tsconfigs[tsconfig]
+			if !ok {
+				paths = readTsconfigPaths(tsconfig)
+				tsconfigs[tsconfig] = paths
+				configs = append(configs, paths.files...)
+			}
+			bases = paths.candidates(imp.specifier)
+		}
+		for _, base := range bases {
+			for _, candidate := range resolveCandidates(base) {
+				if seen[candidate] {
+					continue
+				}
+				seen[candidate] = true
+				if _, err := os.Stat(candidate); errors.Is(err, fs.ErrNotExist) {
+					absent = append(absent, candidate)
+				}
+			}
+		}
+	}
+	return configs, absent
+}
+
+func isRelativeSpecifier(specifier string) bool {
+	return specifier == "." || specifier == ".." ||
+		strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../")
+}
+
+// resolveCandidates lists the files esbuild may resolve an import of base to:
+// base itself, with each extension, and as a directory.
+func resolveCandidates(base string) []string {
+	candidates := []string{base}
+	for _, ext := range resolveExtensions {
+		candidates = append(candidates, base+ext)
+	}
+	candidates = append(candidates, filepath.Join(base, "package.json"))
+	for _, ext := range resolveExtensions {
+		candidates = append(candidates, filepath.Join(base, "index"+ext))
+	}
+	return candidates
+}
+
+// nearestTsconfig returns the tsconfig.json or jsconfig.json that applies to
+// files in dir, or "" when there is none.
+func nearestTsconfig(dir string) string {
+	for {
+		for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// tsconfigPaths is the `paths` mapping of a tsconfig, after `extends`.
+type tsconfigPaths struct {
+	// files are the config files read, the tsconfig and what it extends.
+	files []string
+	// baseURL is the absolute directory bare imports may resolve against.
+	baseURL string
+	// base is the absolute directory the targets of paths are relative to.
+	base  string
+	paths map[string][]string
+}
+
+// readTsconfigPaths reads the `baseUrl` and `paths` of a tsconfig, following
+// relative `extends`. A config that cannot be read maps nothing, as esbuild
+// would report the problem itself.
+func readTsconfigPaths(path string) *tsconfigPaths {
+	result := &tsconfigPaths{}
+	// The chain runs from the config itself to what it extends; options of
+	// a config take precedence over those it extends.
+	for depth := 0; path != "" && depth < 16; depth++ {
+		result.files = append(result.files, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			break
+		}
+		var config struct {
+			Extends         interface{} `json:"extends"`
+			CompilerOptions struct {
+				BaseURL *string             `json:"baseUrl"`
+				Paths   map[string][]string `json:"paths"`
+			} `json:"compilerOptions"`
+		}
+		if err := json.Unmarshal(stripJSONComments(data), &config); err != nil {
+			break
+		}
+		dir := filepath.Dir(path)
+		if options := config.CompilerOptions; options.BaseURL != nil && result.baseURL == "" {
+			result.baseURL = filepath.Join(dir, filepath.FromSlash(*options.BaseURL))
+		}
+		if config.CompilerOptions.Paths != nil && result.paths == nil {
+			result.paths = config.CompilerOptions.Paths
+			result.base = dir
+		}
+
+		path = ""
+		if extends, ok := config.Extends.(string); ok && isRelativeSpecifier(extends) {
+			path = filepath.Join(dir, filepath.FromSlash(extends))
+			if filepath.Ext(path) != ".json" {
+				path += ".json"
+			}
+		}
+	}
+	if result.baseURL != "" {
+		result.base = result.baseURL
+	}
+	return result
+}
+
+// candidates returns the paths, without extension, a bare import may resolve
+// to through `paths` or `baseUrl`.
+func (t *tsconfigPaths) candidates(specifier string) []string {
+	var bases []string
+	for pattern, targets := range t.paths {
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		var match string
+		switch {
+		case !wildcard && specifier == pattern:
+		case wildcard && len(specifier) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(specifier, prefix) && strings.HasSuffix(specifier, suffix):
+			match = specifier[len(prefix) : len(specifier)-len(suffix)]
+		default:
+			continue
+		}
+		for _, target := range targets {
+			target = strings.Replace(target, "*", match, 1)
+			bases = append(bases, filepath.Join(t.base, filepath.FromSlash(target)))
+		}
+	}
+	if t.baseURL != "" {
+		bases = append(bases, filepath.Join(t.baseURL, filepath.FromSlash(specifier)))
+	}
+	return bases
+}
+
+// stripJSONComments removes the comments and trailing commas tsconfig files
+// allow, leaving plain JSON.
+func stripJSONComments(data []byte) []byte {
+	return stripTrailingCommas(stripComments(data))
+}
+
+func stripComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				}
+				i++
+			}
+			i++
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(data) {
+				out = append(out, c)
+				i++
+				c = data[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			j := i + 1
+			for j < len(data) && strings.IndexByte(" \t\r\n", data[j]) >= 0 {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonSchemaTarget generates a JSON Schema (draft 2020-12) document for each
+// schema, `<Name>.schema.json`, describing a stored document.
+type jsonSchemaTarget struct{}
+
+func init() {
+	registerTarget(jsonSchemaTarget{})
+}
+
+func (jsonSchemaTarget) Name() string { return "json-schema" }
+
+// objectIdPattern matches the hex string form of an ObjectId.
+const objectIdPattern = "^[0-9a-fA-F]{24}$"
+
+func (jsonSchemaTarget) RenderSchema(schema Schema, ctx *RenderContext) ([]OutputFile, error) {
+	doc := &orderedObject{}
+	doc.set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	doc.set("title", schema.Name)
+	if schema.Description != "" {
+		doc.set("description", schema.Description)
+	}
+
+	additional := ctx.Config.AdditionalProperties
+	jsonSchemaObject(doc, documentFields(schema), additional)
+
+	if len(schema.SubDocuments) > 0 {
+		defs := &orderedObject{}
+		for _, sub := range schema.SubDocuments {
+			def := &orderedObject{}
+			jsonSchemaObject(def, sub.Fields, additional)
+			defs.set(sub.Name, def)
+		}
+		doc.set("$defs", defs)
+	}
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{{Path: schema.Name + ".schema.json", Content: append(content, '\n')}}, nil
+}
+
+func (jsonSchemaTarget) RenderShared(schemas []Schema, ctx *RenderContext) ([]OutputFile, error) {
+	return nil, nil
+}
+
+// jsonSchemaObject fills obj with the object keywords for fields.
+func jsonSchemaObject(obj *orderedObject, fields []Field, additional bool) {
+	setObjectKeywords(obj, "type", fields, additional, func(field Field) *orderedObject {
+		return jsonSchemaField(field, additional)
+	})
+}
+
+// jsonSchemaField renders the JSON Schema of a single field.
+func jsonSchemaField(field Field, additional bool) *orderedObject {
+	s := &orderedObject{}
+	if field.Description != "" {
+		s.set("description", field.Description)
+	}
+
+	switch field.Type {
+	case "string":
+		s.set("type", "string")
+		if len(field.Enum) > 0 {
+			s.set("enum", field.Enum)
+		}
+		if field.MinLength != nil {
+			s.set("minLength", *field.MinLength)
+		}
+		if field.MaxLength != nil {
+			s.set("maxLength", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			s.set("pattern", field.Pattern)
+		}
+	case "number":
+		s.set("type", "number")
+		if field.Min != nil {
+			s.set("minimum", *field.Min)
+		}
+		if field.Max != nil {
+			s.set("maximum", *field.Max)
+		}
+	case "boolean":
+		s.set("type", "boolean")
+	case "date":
+		s.set("type", "string")
+		s.set("format", "date-time")
+	case "objectId":
+		s.set("type", "string")
+		s.set("pattern", objectIdPattern)
+	case "object":
+		if field.SubDocument != "" {
+			s.set("$ref", "#/$defs/"+field.SubDocument)
+		} else {
+			jsonSchemaObject(s, field.Fields, additional)
+		}
+	case "array":
+		s.set("type", "array")
+		if field.Items != nil {
+			s.set("items", jsonSchemaField(*field.Items, additional))
+		}
+		if field.MinItems != nil {
+			s.set("minItems", *field.MinItems)
+		}
+		if field.MaxItems != nil {
+			s.set("maxItems", *field.MaxItems)
+		}
+	}
+
+	// A "now" date default has no JSON value; the database fills it in.
+	if field.Default != nil && field.Default != dateNowDefault {
+		s.set("default", field.Default)
+	}
+	return s
+}
+
+// orderedObject is a JSON object that keeps its keys in insertion order, so
+// properties read in the order they were declared.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) set(key string, value interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
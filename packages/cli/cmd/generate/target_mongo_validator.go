@@ -0,0 +1,153 @@
+package generate
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// mongoValidatorTarget generates MongoDB server-side validation: a
+// `<db>/<collection>.json` validator document per schema and an
+// `apply-validators.js` mongosh script that installs them with collMod or
+// createCollection.
+//
+// Unless the config's additionalProperties allows them, documents and
+// subdocuments reject fields the schema does not declare, so `_id` and the
+// timestamps are declared explicitly.
+type mongoValidatorTarget struct{}
+
+func init() {
+	registerTarget(mongoValidatorTarget{})
+	registerTemplate("mongo-validators.tmpl", validatorsData)
+}
+
+func (mongoValidatorTarget) Name() string { return "mongo-validator" }
+
+func (mongoValidatorTarget) RenderSchema(schema Schema, ctx *RenderContext) ([]OutputFile, error) {
+	content, err := validatorJSON(schema, ctx.Config.AdditionalProperties)
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{{
+		Path:    path.Join(schema.DB, collectionFileName(schema.Collection)+".json"),
+		Content: append([]byte(content), '\n'),
+	}}, nil
+}
+
+func (mongoValidatorTarget) RenderShared(schemas []Schema, ctx *RenderContext) ([]OutputFile, error) {
+	validators, err := newMongoValidators(schemas, ctx.Config.AdditionalProperties)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ctx.Templates.Execute("mongo-validators.tmpl", validators)
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{{Path: "apply-validators.js", Content: content}}, nil
+}
+
+// mongoValidator is an entry of the data of mongo-validators.tmpl.
+type mongoValidator struct {
+	Schema Schema
+	// Validator is the schema's `{ $jsonSchema: ... }` validator as JSON.
+	Validator string
+}
+
+func newMongoValidators(schemas []Schema, additional bool) ([]mongoValidator, error) {
+	validators := make([]mongoValidator, 0, len(schemas))
+	for _, schema := range schemas {
+		validator, err := validatorJSON(schema, additional)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, mongoValidator{Schema: schema, Validator: validator})
+	}
+	return validators, nil
+}
+
+// collectionFileName turns a collection name, which may contain `/`, into a
+// single file name. `%` is escaped too, so distinct names stay distinct. The
+// database name needs no escaping: validation rejects `/`, `\` and `.` in it.
+func collectionFileName(collection string) string {
+	return strings.NewReplacer("%", "%25", "/", "%2F", `\`, "%5C").Replace(collection)
+}
+
+// validatorJSON renders the `{ $jsonSchema: ... }` validator of a schema.
+func validatorJSON(schema Schema, additional bool) (string, error) {
+	jsonSchema := &orderedObject{}
+	jsonSchema.set("title", schema.Name)
+	if schema.Description != "" {
+		jsonSchema.set("description", schema.Description)
+	}
+	bsonObject(jsonSchema, documentFields(schema), additional)
+
+	validator := &orderedObject{}
+	validator.set("$jsonSchema", jsonSchema)
+	content, err := json.MarshalIndent(validator, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// bsonObject fills obj with the object keywords for fields.
+func bsonObject(obj *orderedObject, fields []Field, additional bool) {
+	setObjectKeywords(obj, "bsonType", fields, additional, func(field Field) *orderedObject {
+		return bsonField(field, additional)
+	})
+}
+
+// bsonField renders the $jsonSchema of a single field. MongoDB supports
+// neither $ref nor default, so subdocuments are inlined and defaults left to
+// the application.
+func bsonField(field Field, additional bool) *orderedObject {
+	s := &orderedObject{}
+	switch field.Type {
+	case "string":
+		s.set("bsonType", "string")
+		if len(field.Enum) > 0 {
+			s.set("enum", field.Enum)
+		}
+		if field.MinLength != nil {
+			s.set("minLength", *field.MinLength)
+		}
+		if field.MaxLength != nil {
+			s.set("maxLength", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			s.set("pattern", field.Pattern)
+		}
+	case "number":
+		// "number" matches double, int, long and decimal alike.
+		s.set("bsonType", "number")
+		if field.Min != nil {
+			s.set("minimum", *field.Min)
+		}
+		if field.Max != nil {
+			s.set("maximum", *field.Max)
+		}
+	case "boolean":
+		s.set("bsonType", "bool")
+	case "date":
+		s.set("bsonType", "date")
+	case "objectId":
+		s.set("bsonType", "objectId")
+	case "object":
+		bsonObject(s, field.Fields, additional)
+	case "array":
+		s.set("bsonType", "array")
+		if field.Items != nil {
+			s.set("items", bsonField(*field.Items, additional))
+		}
+		if field.MinItems != nil {
+			s.set("minItems", *field.MinItems)
+		}
+		if field.MaxItems != nil {
+			s.set("maxItems", *field.MaxItems)
+		}
+	}
+	if field.Description != "" {
+		s.set("description", field.Description)
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package generate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidatorRequiresOnlyRequiredFields(t *testing.T) {
+	content, err := validatorJSON(sampleSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var validator struct {
+		JSONSchema struct {
+			Required             []string               `json:"required"`
+			AdditionalProperties *bool                  `json:"additionalProperties"`
+			Properties           map[string]interface{} `json:"properties"`
+		} `json:"$jsonSchema"`
+	}
+	if err := json.Unmarshal([]byte(content), &validator); err != nil {
+		t.Fatal(err)
+	}
+	// Defaulted fields and timestamps are left out: create() sets neither.
+	if want := []string{"_id", "name", "tags"}; !reflect.DeepEqual(validator.JSONSchema.Required, want) {
+		t.Errorf("required = %v; want %v", validator.JSONSchema.Required, want)
+	}
+	if p := validator.JSONSchema.AdditionalProperties; p == nil || *p {
+		t.Errorf("additionalProperties = %v; want false", p)
+	}
+	if _, ok := validator.JSONSchema.Properties["createdAt"]; !ok {
+		t.Error("createdAt is not declared")
+	}
+
+	content, err = validatorJSON(sampleSchema, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(content, "additionalProperties") {
+		t.Errorf("validator allowing additional properties still sets the keyword:\n%s", content)
+	}
+}
+
+func TestCollectionFileNameStaysInDirectory(t *testing.T) {
+	for collection, want := range map[string]string{
+		"users":       "users",
+		"../../etc":   "..%2F..%2Fetc",
+		`a\b`:         "a%5Cb",
+		"50%/off":     "50%25%2Foff",
+		"events.2024": "events.2024",
+	} {
+		if got := collectionFileName(collection); got != want {
+			t.Errorf("collectionFileName(%q) = %q; want %q", collection, got, want)
+		}
+	}
+}
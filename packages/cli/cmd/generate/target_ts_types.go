@@ -0,0 +1,119 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tsTypesTarget generates plain TypeScript types with no runtime validation,
+// one `<Name>.types.ts` per schema.
+type tsTypesTarget struct{}
+
+func init() {
+	registerTarget(tsTypesTarget{})
+	registerTemplate("ts-types.tmpl", schemaData)
+}
+
+func (tsTypesTarget) Name() string { return "ts-types" }
+
+func (tsTypesTarget) RenderSchema(schema Schema, ctx *RenderContext) ([]OutputFile, error) {
+	content, err := ctx.Templates.Execute("ts-types.tmpl", schema)
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{{Path: schema.Name + ".types.ts", Content: content}}, nil
+}
+
+func (tsTypesTarget) RenderShared(schemas []Schema, ctx *RenderContext) ([]OutputFile, error) {
+	return nil, nil
+}
+
+// tsPresent reports whether a field is always set on a stored document: it is
+// required, or filled in from its default.
+func tsPresent(field Field) bool {
+	return field.Required || field.Default != nil
+}
+
+// tsType renders the TypeScript type of a field.
+func tsType(field Field) string {
+	switch field.Type {
+	case "string":
+		if len(field.Enum) > 0 {
+			values := make([]string, len(field.Enum))
+			for i, v := range field.Enum {
+				values[i] = jsString(v)
+			}
+			return strings.Join(values, " | ")
+		}
+		return "string"
+	case "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "date":
+		return "Date"
+	case "objectId":
+		return "ObjectId"
+	case "object":
+		if field.SubDocument != "" {
+			return field.SubDocument
+		}
+		parts := make([]string, 0, len(field.Fields))
+		for _, f := range field.Fields {
+			optional := "?"
+			if tsPresent(f) {
+				optional = ""
+			}
+			parts = append(parts, fmt.Sprintf("%s%s: %s", tsKey(f.Name), optional, tsType(f)))
+		}
+		return fmt.Sprintf("{ %s }", strings.Join(parts, "; "))
+	case "array":
+		item := "unknown"
+		if field.Items != nil {
+			item = tsType(*field.Items)
+		}
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	}
+	return "unknown"
+}
+
+// createOmitted lists the keys a create input leaves to the database.
+func createOmitted(schema Schema) []string {
+	keys := []string{"_id"}
+	if schema.Options.Timestamps {
+		keys = append(keys, "createdAt", "updatedAt")
+	}
+	return append(keys, defaultedFields(schema)...)
+}
+
+// defaultedFields lists the fields a create input may leave out because they
+// have a default.
+func defaultedFields(schema Schema) []string {
+	var names []string
+	for _, field := range schema.Fields {
+		if field.Default != nil {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// tsKey renders name as an object key, quoted unless it is an identifier.
+func tsKey(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return jsString(name)
+}
+
+// tsKeys renders names as a union of string literal types.
+func tsKeys(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return strings.Join(quoted, " | ")
+}
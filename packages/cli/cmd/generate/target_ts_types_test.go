@@ -0,0 +1,32 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeysThatAreNotIdentifiersAreQuoted(t *testing.T) {
+	tests := map[string]string{
+		"firstName":  "firstName",
+		"$type":      "$type",
+		"first-name": `"first-name"`,
+		"2fa":        `"2fa"`,
+		"a b":        `"a b"`,
+	}
+	for name, want := range tests {
+		if got := tsKey(name); got != want {
+			t.Errorf("tsKey(%q) = %s; want %s", name, got, want)
+		}
+	}
+
+	field := Field{Name: "profile", Type: "object", Fields: []Field{
+		{Name: "first-name", Type: "string", Required: true},
+		{Name: "age", Type: "number"},
+	}}
+	if got, want := tsType(field), `{ "first-name": string; age?: number }`; got != want {
+		t.Errorf("tsType = %s; want %s", got, want)
+	}
+	if got := zodType(field); !strings.Contains(got, `"first-name": z.string()`) {
+		t.Errorf("zodType = %s; want the key quoted", got)
+	}
+}
@@ -0,0 +1,208 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// zodTarget generates Zod schemas and the types inferred from them, one
+// `<Name>.schema.ts` per schema plus the shared `utils.ts` and, unless the
+// config's index is "none", an `index.ts` barrel.
+type zodTarget struct{}
+
+func init() {
+	registerTarget(zodTarget{})
+	registerTemplate("zod.tmpl", schemaData)
+	registerTemplate("utils.tmpl", schemasData)
+	registerTemplate("index.tmpl", indexData)
+}
+
+func (zodTarget) Name() string { return "zod" }
+
+func (zodTarget) RenderSchema(schema Schema, ctx *RenderContext) ([]OutputFile, error) {
+	content, err := ctx.Templates.Execute("zod.tmpl", schema)
+	if err != nil {
+		return nil, err
+	}
+	return []OutputFile{{Path: schema.Name + ".schema.ts", Content: content}}, nil
+}
+
+// RenderShared always writes utils.ts, since every schema's _id uses ObjectIdSchema.
+func (zodTarget) RenderShared(schemas []Schema, ctx *RenderContext) ([]OutputFile, error) {
+	content, err := ctx.Templates.Execute("utils.tmpl", schemas)
+	if err != nil {
+		return nil, err
+	}
+	files := []OutputFile{{Path: "utils.ts", Content: content}}
+
+	if ctx.Config.Index == IndexNone {
+		return files, nil
+	}
+	content, err = ctx.Templates.Execute("index.tmpl", newZodIndex(schemas, ctx.Config.Index == IndexNamespaced))
+	if err != nil {
+		return nil, err
+	}
+	return append(files, OutputFile{Path: "index.ts", Content: content}), nil
+}
+
+// zodIndex is the data of index.tmpl.
+type zodIndex struct {
+	// Namespaced re-exports each schema file as a namespace named after the
+	// schema instead of re-exporting its names.
+	Namespaced bool
+	Entries    []zodIndexEntry
+}
+
+type zodIndexEntry struct {
+	Schema Schema
+	// SubDocuments are the subdocument schemas re-exported from this file.
+	// Every schema file embedding a subdocument declares its own copy, so each
+	// is only re-exported from the first to keep flat names unique.
+	SubDocuments []string
+}
+
+func newZodIndex(schemas []Schema, namespaced bool) zodIndex {
+	sorted := append([]Schema{}, schemas...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	index := zodIndex{Namespaced: namespaced}
+	// A subdocument named like a schema would clash with that schema's export.
+	exported := make(map[string]bool)
+	for _, schema := range sorted {
+		exported[schema.Name] = true
+	}
+	for _, schema := range sorted {
+		entry := zodIndexEntry{Schema: schema}
+		for _, sub := range schema.SubDocuments {
+			if !exported[sub.Name] {
+				exported[sub.Name] = true
+				entry.SubDocuments = append(entry.SubDocuments, sub.Name)
+			}
+		}
+		index.Entries = append(index.Entries, entry)
+	}
+	return index
+}
+
+// Zod is the resolved Zod expression of the field, as rendered by zodType.
+func (f Field) Zod() string {
+	return zodType(f)
+}
+
+func zodType(field Field) string {
+	zodSchema := zodBaseType(field)
+
+	// A default already makes the input optional; adding .optional() on top
+	// would stop Zod from ever applying it.
+	if field.Default != nil {
+		zodSchema += fmt.Sprintf(".default(%s)", zodDefault(field))
+	} else if !field.Required {
+		zodSchema += ".optional()"
+	}
+
+	if field.Description != "" {
+		zodSchema += fmt.Sprintf(".describe(%s)", jsString(field.Description))
+	}
+
+	return zodSchema
+}
+
+// zodBaseType renders the Zod expression for a field without its optionality.
+func zodBaseType(field Field) string {
+	var zodSchema string
+
+	switch field.Type {
+	case "string":
+		if len(field.Enum) > 0 {
+			values := make([]string, len(field.Enum))
+			for i, v := range field.Enum {
+				values[i] = jsString(v)
+			}
+			zodSchema = fmt.Sprintf("z.enum([%s])", strings.Join(values, ", "))
+			break
+		}
+		zodSchema = "z.string()"
+		if field.MinLength != nil {
+			zodSchema += fmt.Sprintf(".min(%d)", *field.MinLength)
+		}
+		if field.MaxLength != nil {
+			zodSchema += fmt.Sprintf(".max(%d)", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			zodSchema += fmt.Sprintf(".regex(new RegExp(%s))", jsString(field.Pattern))
+		}
+	case "number":
+		zodSchema = "z.number()"
+		if field.Min != nil {
+			zodSchema += fmt.Sprintf(".min(%s)", jsNumber(*field.Min))
+		}
+		if field.Max != nil {
+			zodSchema += fmt.Sprintf(".max(%s)", jsNumber(*field.Max))
+		}
+	case "boolean":
+		zodSchema = "z.boolean()"
+	case "date":
+		zodSchema = "z.date()"
+	case "objectId":
+		zodSchema = "ObjectIdSchema"
+	case "object":
+		if field.SubDocument != "" {
+			zodSchema = field.SubDocument + "Schema"
+		} else {
+			zodSchema = zodObject(field.Fields)
+		}
+	case "array":
+		item := "z.any()"
+		if field.Items != nil {
+			item = zodBaseType(*field.Items)
+		}
+		zodSchema = fmt.Sprintf("z.array(%s)", item)
+		if field.MinItems != nil {
+			zodSchema += fmt.Sprintf(".min(%d)", *field.MinItems)
+		}
+		if field.MaxItems != nil {
+			zodSchema += fmt.Sprintf(".max(%d)", *field.MaxItems)
+		}
+	default:
+		zodSchema = "z.any()"
+	}
+
+	return zodSchema
+}
+
+// zodObject renders an inline z.object() for fields.object() definitions.
+func zodObject(fields []Field) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", tsKey(field.Name), zodType(field)))
+	}
+
+	return fmt.Sprintf("z.object({ %s })", strings.Join(parts, ", "))
+}
+
+// zodDefault renders a field's default value as a JavaScript expression.
+func zodDefault(field Field) string {
+	if field.Type == "date" {
+		if field.Default == dateNowDefault {
+			return "() => new Date()"
+		}
+		switch def := field.Default.(type) {
+		case string:
+			return fmt.Sprintf("() => new Date(%s)", jsString(def))
+		case float64:
+			return fmt.Sprintf("() => new Date(%s)", jsNumber(def))
+		}
+	}
+
+	switch def := field.Default.(type) {
+	case string:
+		return jsString(def)
+	case float64:
+		return jsNumber(def)
+	case bool:
+		return strconv.FormatBool(def)
+	}
+	return "undefined"
+}
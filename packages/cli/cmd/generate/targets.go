@@ -0,0 +1,156 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Target renders schemas into the files of one output format. Each target
+// lives in its own target_*.go file and registers itself, and any templates
+// it executes, from init.
+type Target interface {
+	// Name is the key used in monkko.config.json and by --target.
+	Name() string
+	// RenderSchema renders the files belonging to a single schema.
+	RenderSchema(schema Schema, ctx *RenderContext) ([]OutputFile, error)
+	// RenderShared renders files written once per output directory, such as
+	// helpers the schema files import.
+	RenderShared(schemas []Schema, ctx *RenderContext) ([]OutputFile, error)
+}
+
+// RenderContext is what targets render with besides the schemas.
+type RenderContext struct {
+	Templates *Templates
+	Config    *Config
+}
+
+// OutputFile is a rendered file, with Path relative to the target's output
+// directory.
+type OutputFile struct {
+	Path    string
+	Content []byte
+}
+
+// defaultTarget is generated when neither the config nor --target picks one.
+const defaultTarget = "zod"
+
+var targets = make(map[string]Target)
+
+// registerTarget makes a target available to the config and --target.
+func registerTarget(t Target) {
+	if _, exists := targets[t.Name()]; exists {
+		panic(fmt.Sprintf("target %q registered twice", t.Name()))
+	}
+	targets[t.Name()] = t
+}
+
+// targetNames returns the registered target names, sorted.
+func targetNames() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TargetConfig selects a target and where its files go. In monkko.config.json
+// it is either an object or just the target name, which writes to outputDir.
+type TargetConfig struct {
+	Name      string `json:"name"`
+	OutputDir string `json:"outputDir,omitempty"`
+}
+
+func (t *TargetConfig) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		t.Name = name
+		return nil
+	}
+	type plain TargetConfig
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// resolveTargets returns the targets to generate. The config's targets are
+// used, or the default target when there are none; a non-empty selection (from
+// --target) narrows that list, and may name targets the config leaves out.
+// Targets without an output directory write to the config's outputDir.
+func resolveTargets(config *Config, selection []string) ([]TargetConfig, error) {
+	configured := config.Targets
+	if len(configured) == 0 {
+		configured = []TargetConfig{{Name: defaultTarget}}
+	}
+
+	resolved := configured
+	if len(selection) > 0 {
+		resolved = nil
+		for _, name := range selection {
+			name = strings.TrimSpace(name)
+			selected := TargetConfig{Name: name}
+			for _, tc := range configured {
+				if tc.Name == name {
+					selected = tc
+					break
+				}
+			}
+			resolved = append(resolved, selected)
+		}
+	}
+
+	seen := make(map[TargetConfig]bool)
+	var result []TargetConfig
+	for _, tc := range resolved {
+		if _, ok := targets[tc.Name]; !ok {
+			return nil, fmt.Errorf("unknown target '%s'%s (available: %s)", tc.Name, didYouMean(tc.Name, targetNames()), strings.Join(targetNames(), ", "))
+		}
+		if tc.OutputDir == "" {
+			tc.OutputDir = config.OutputDir
+		}
+		if !seen[tc] {
+			seen[tc] = true
+			result = append(result, tc)
+		}
+	}
+	return result, nil
+}
+
+// documentFields returns the fields of a stored document, as the json-schema
+// and mongo-validator targets describe it: `_id`, the schema's fields and,
+// with timestamps, createdAt and updatedAt.
+func documentFields(schema Schema) []Field {
+	fields := append([]Field{{Name: "_id", Type: "objectId", Required: true}}, schema.Fields...)
+	if schema.Options.Timestamps {
+		// The ORM's create() sets no timestamps, so they may be missing.
+		fields = append(fields,
+			Field{Name: "createdAt", Type: "date"},
+			Field{Name: "updatedAt", Type: "date"},
+		)
+	}
+	return fields
+}
+
+// setObjectKeywords fills obj with the JSON Schema keywords of an object with
+// fields, whose type is named by typeKey ("type", or "bsonType" for MongoDB).
+// Only required fields are required: create() fills in no defaults, so a
+// defaulted field may be missing from a stored document. Undeclared fields are
+// rejected unless the config's additionalProperties allows them.
+func setObjectKeywords(obj *orderedObject, typeKey string, fields []Field, additional bool, render func(Field) *orderedObject) {
+	properties := &orderedObject{}
+	var required []string
+	for _, field := range fields {
+		properties.set(field.Name, render(field))
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+	obj.set(typeKey, "object")
+	if len(required) > 0 {
+		obj.set("required", required)
+	}
+	obj.set("properties", properties)
+	if !additional {
+		obj.set("additionalProperties", false)
+	}
+}
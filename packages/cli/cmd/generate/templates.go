@@ -0,0 +1,277 @@
+package generate
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplateFS embed.FS
+
+// templateData is what a template is executed with.
+type templateData int
+
+const (
+	// schemaData templates render one schema and receive a Schema.
+	schemaData templateData = iota
+	// schemasData templates render shared files and receive []Schema.
+	schemasData
+	// indexData is the zod target's index.tmpl, which receives a zodIndex.
+	indexData
+	// validatorsData is the mongo-validator target's mongo-validators.tmpl,
+	// which receives a []mongoValidator.
+	validatorsData
+)
+
+// builtinTemplates maps the file name of each built-in template to its data.
+var builtinTemplates = make(map[string]templateData)
+
+// registerTemplate declares a built-in template from the templates directory,
+// normally from the init of the target that executes it.
+func registerTemplate(name string, data templateData) {
+	builtinTemplates[name] = data
+}
+
+// templateFuncs are available to every template; see docs/templates.md.
+var templateFuncs = template.FuncMap{
+	"zodType":         zodType,
+	"tsType":          tsType,
+	"tsPresent":       tsPresent,
+	"tsKey":           tsKey,
+	"tsKeys":          tsKeys,
+	"createOmitted":   createOmitted,
+	"defaultedFields": defaultedFields,
+	"jsDoc":           jsDoc,
+	"jsString":        jsString,
+	"jsNumber":        jsNumber,
+	"camel":           camel,
+	"pascal":          pascal,
+	"plural":          plural,
+	"printf":          fmt.Sprintf,
+}
+
+// Templates are the parsed templates of a run: the built-in ones, each
+// replaced by the file of the same name in templatesDir when there is one.
+type Templates struct {
+	parsed map[string]*template.Template
+	// sources names where each template came from, for messages.
+	sources map[string]string
+}
+
+// LoadTemplates parses every template once and renders each override with a
+// sample schema, so that a broken template is reported before any schema is
+// parsed instead of once per schema.
+func LoadTemplates(templatesDir string, debug bool) (*Templates, Diagnostics) {
+	t := &Templates{
+		parsed:  make(map[string]*template.Template),
+		sources: make(map[string]string),
+	}
+	var diags Diagnostics
+
+	overrides := make(map[string]string)
+	if templatesDir != "" {
+		entries, err := os.ReadDir(templatesDir)
+		if err != nil {
+			return nil, Diagnostics{newError(templatesDir, CodeReadFailed, "failed to read templatesDir: %v", err)}
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".tmpl" {
+				continue
+			}
+			if _, ok := builtinTemplates[name]; !ok {
+				d := newError(filepath.Join(templatesDir, name), CodeTemplateFailed, "'%s' does not override a built-in template%s", name, didYouMean(name, builtinTemplateNames()))
+				d.Severity = SeverityWarning
+				diags = append(diags, d)
+				continue
+			}
+			overrides[name] = filepath.Join(templatesDir, name)
+		}
+	}
+
+	for _, name := range builtinTemplateNames() {
+		source := "built-in " + name
+		text, err := builtinTemplateFS.ReadFile("templates/" + name)
+		if path, ok := overrides[name]; ok {
+			source = path
+			text, err = os.ReadFile(path)
+			if debug {
+				fmt.Printf("🎨 Using template override %s\n", path)
+			}
+		}
+		if err != nil {
+			diags = append(diags, newError(source, CodeReadFailed, "failed to read template: %v", err))
+			continue
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(text))
+		if err != nil {
+			diags = append(diags, newError(source, CodeTemplateFailed, "%v", err))
+			continue
+		}
+		t.parsed[name] = tmpl
+		t.sources[name] = source
+	}
+
+	for name := range overrides {
+		tmpl, ok := t.parsed[name]
+		if !ok {
+			continue
+		}
+		var samples []interface{}
+		switch builtinTemplates[name] {
+		case schemaData:
+			samples = []interface{}{sampleSchema}
+		case schemasData:
+			samples = []interface{}{[]Schema{sampleSchema}}
+		case indexData:
+			samples = []interface{}{newZodIndex([]Schema{sampleSchema}, false), newZodIndex([]Schema{sampleSchema}, true)}
+		case validatorsData:
+			validators, err := newMongoValidators([]Schema{sampleSchema}, false)
+			if err != nil {
+				diags = append(diags, newError(overrides[name], CodeTemplateFailed, "%v", err))
+				continue
+			}
+			samples = []interface{}{validators}
+		}
+		for _, sample := range samples {
+			if err := tmpl.Execute(io.Discard, sample); err != nil {
+				diags = append(diags, newError(overrides[name], CodeTemplateFailed, "%v", err))
+				break
+			}
+		}
+	}
+
+	return t, diags
+}
+
+// Execute renders the named template with data.
+func (t *Templates) Execute(name string, data interface{}) ([]byte, error) {
+	tmpl, ok := t.parsed[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s is not loaded", name)
+	}
+	var result strings.Builder
+	if err := tmpl.Execute(&result, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", t.sources[name], err)
+	}
+	return []byte(result.String()), nil
+}
+
+func builtinTemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sampleSchema exercises every field type and option, for checking templates.
+var sampleSchema = func() Schema {
+	minimum, count := 0.0, 1
+	address := []Field{
+		{Name: "street", Type: "string", Required: true},
+		{Name: "city", Type: "string", Description: "City name"},
+	}
+	return Schema{
+		Name:        "Sample",
+		Description: "A sample schema",
+		DB:          "sample",
+		Collection:  "samples",
+		Fields: []Field{
+			{Name: "name", Type: "string", Required: true, MinLength: &count, MaxLength: &count, Pattern: "^a"},
+			{Name: "role", Type: "string", Enum: []string{"admin", "member"}, Default: "member"},
+			{Name: "age", Type: "number", Min: &minimum, Max: &minimum},
+			{Name: "active", Type: "boolean", Default: true},
+			{Name: "joinedAt", Type: "date", Default: dateNowDefault},
+			{Name: "ownerId", Type: "objectId", Ref: "Sample"},
+			{Name: "address", Type: "object", SubDocument: "Address", Fields: address},
+			{Name: "meta", Type: "object", Fields: []Field{{Name: "note", Type: "string"}}},
+			{Name: "tags", Type: "array", Required: true, Items: &Field{Type: "string"}, MinItems: &count, MaxItems: &count},
+		},
+		Options:      Options{Timestamps: true},
+		SubDocuments: []SubDocument{{Name: "Address", Fields: address}},
+	}
+}()
+
+// words splits an identifier such as "userProfile", "user_profile" or
+// "user-profile" into its words.
+func words(s string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				result = append(result, string(current))
+				current = nil
+			}
+			continue
+		}
+		// A new word starts at an upper-case letter after a lower-case one,
+		// or at the last capital of an acronym ("HTTPServer" -> HTTP, Server).
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				result = append(result, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		result = append(result, string(current))
+	}
+	return result
+}
+
+// pascal converts s to PascalCase: "user_profile" becomes "UserProfile".
+func pascal(s string) string {
+	var sb strings.Builder
+	for _, word := range words(s) {
+		runes := []rune(word)
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+	return sb.String()
+}
+
+// camel converts s to camelCase: "UserProfile" becomes "userProfile".
+func camel(s string) string {
+	var sb strings.Builder
+	for i, word := range words(s) {
+		runes := []rune(word)
+		if i == 0 {
+			sb.WriteString(strings.ToLower(word))
+			continue
+		}
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+	return sb.String()
+}
+
+// plural returns the English plural of a singular noun using the regular
+// rules: "Category" becomes "Categories", "Address" becomes "Addresses".
+func plural(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsAny(lower[len(lower)-2:len(lower)-1], "aeiou"):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	}
+	return s + "s"
+}
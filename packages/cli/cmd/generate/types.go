@@ -1,18 +1,74 @@
 package generate
 
 type Schema struct {
-	Name       string           `json:"name"`
-	DB         string           `json:"db"`
-	Collection string           `json:"collection"`
-	Fields     map[string]Field `json:"fields"`
-	Options    Options          `json:"options"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	DB          string `json:"db"`
+	Collection  string `json:"collection"`
+	// Fields are in declaration order.
+	Fields       []Field       `json:"fields"`
+	Options      Options       `json:"options"`
+	SubDocuments []SubDocument `json:"subDocuments,omitempty"`
+	// References are the objectId refs of the schema, checked against every
+	// schema of the run once parsing is done.
+	References []Reference `json:"references,omitempty"`
+	// File, Line and Column are where the schema is defined, so that problems
+	// found across schemas, such as two sharing a name, are reported there.
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
 }
 
 type Field struct {
-	Type     string `json:"type"`
-	Required bool   `json:"required"`
-	Unique   bool   `json:"unique"`
-	Optional bool   `json:"optional"`
+	// Name is the key the field is declared under; array items have none.
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+	// Description is the JSDoc comment written above the field.
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Unique      bool   `json:"unique"`
+	Optional    bool   `json:"optional"`
+	// SubDocument is the name of the defineSubDocument binding this field
+	// was built from. Inline fields.object() fields leave it empty.
+	SubDocument string  `json:"subDocument,omitempty"`
+	Fields      []Field `json:"fields,omitempty"`
+	// Items is the element type of an array field.
+	Items    *Field `json:"items,omitempty"`
+	MinItems *int   `json:"minItems,omitempty"`
+	MaxItems *int   `json:"maxItems,omitempty"`
+	// String constraints
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	// Number constraints
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Ref is the schema an objectId field points at.
+	Ref string `json:"ref,omitempty"`
+	// Default is a string, float64 or bool literal. Date fields use
+	// dateNowDefault for "the current time".
+	Default interface{} `json:"default,omitempty"`
+}
+
+// dateNowDefault is the date default sentinel for the current time, written
+// as `"now"`, `new Date()` or `() => new Date()` in a schema.
+const dateNowDefault = "now"
+
+// SubDocument is a named defineSubDocument schema embedded by a Schema.
+type SubDocument struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// Reference is an objectId field's `ref`, kept with the position it was
+// written at so that a dangling target can be reported there.
+type Reference struct {
+	Field  string `json:"field"`
+	Target string `json:"target"`
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
 }
 
 type Options struct {
@@ -23,4 +79,27 @@ type Config struct {
 	OutputDir string   `json:"outputDir"`
 	Includes  []string `json:"includes,omitempty"`
 	Excludes  []string `json:"excludes,omitempty"`
+	// UseIgnoreFiles also skips paths matched by .gitignore,
+	// .git/info/exclude and .monkkoignore files; see ignore.go.
+	UseIgnoreFiles bool `json:"useIgnoreFiles,omitempty"`
+	// Targets picks the output formats; see targets.go.
+	Targets []TargetConfig `json:"targets,omitempty"`
+	// TemplatesDir holds templates that replace built-in ones of the same
+	// file name; see templates.go.
+	TemplatesDir string `json:"templatesDir,omitempty"`
+	// Index selects how the zod target's index.ts re-exports the schemas.
+	Index string `json:"index,omitempty"`
+	// AdditionalProperties lets the json-schema and mongo-validator targets
+	// accept fields a schema does not declare.
+	AdditionalProperties bool `json:"additionalProperties,omitempty"`
 }
+
+// Index modes for Config.Index.
+const (
+	// IndexFlat re-exports every name: `export { UserSchema, ... } from './User.schema'`.
+	IndexFlat = "flat"
+	// IndexNamespaced re-exports each file as a namespace: `export * as User from './User.schema'`.
+	IndexNamespaced = "namespaced"
+	// IndexNone writes no index.ts.
+	IndexNone = "none"
+)
@@ -0,0 +1,465 @@
+package generate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja/file"
+)
+
+// schemaProps are the keys of a defineSchema definition.
+var schemaProps = []string{"name", "db", "collection", "fields", "options"}
+
+// schemaOptions are the keys of a definition's `options` object.
+var schemaOptions = []string{"timestamps"}
+
+// timestampFields are added to every document when `options.timestamps` is set.
+var timestampFields = []string{"createdAt", "updatedAt"}
+
+// baseFieldProps are accepted by every field constructor, mirroring BaseField
+// in @monkko/orm. `type` is injected by the parser.
+var baseFieldProps = []string{"type", "required", "optional", "unique", "transform"}
+
+// fieldProps lists the options each field type accepts on top of
+// baseFieldProps, mirroring the *FieldProps interfaces of @monkko/orm.
+// `schema` and `items` hold the first argument of fields.object and fields.array.
+var fieldProps = map[string][]string{
+	"string":   {"default", "minLength", "maxLength", "pattern", "enum"},
+	"number":   {"default", "min", "max"},
+	"boolean":  {"default"},
+	"date":     {"default"},
+	"objectId": {"default", "ref"},
+	"object":   {"schema"},
+	"array":    {"items", "minItems", "maxItems"},
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// schemaValidator checks a converted defineSchema definition before it is
+// mapped, so that mistakes the mapping would silently drop or paper over are
+// reported at their source position instead.
+type schemaValidator struct {
+	bundle *bundledFile
+	diags  Diagnostics
+	// seen drops repeats, e.g. from a subdocument embedded in several fields.
+	seen map[Diagnostic]bool
+	refs []Reference
+}
+
+// validateSchemaMap checks the definition of the schema called name. The
+// collection defaults to fallbackCollection when the definition has none.
+// It also returns the objectId refs found, for checkReferences.
+func validateSchemaMap(bundle *bundledFile, name, fallbackCollection string, schemaMap map[string]interface{}) (Diagnostics, []Reference) {
+	v := &schemaValidator{bundle: bundle, seen: make(map[Diagnostic]bool)}
+	v.checkKeys(schemaMap, schemaProps, "schema property")
+
+	if rawName, ok := schemaMap["name"]; ok {
+		if s, ok := rawName.(string); !ok || !identifierPattern.MatchString(s) {
+			v.errorAt(schemaMap, "name", CodeInvalidValue, "schema name must be a valid identifier, got %s", describeValue(rawName))
+		}
+	}
+
+	db, hasDB := schemaMap["db"]
+	if !hasDB {
+		v.errorAt(schemaMap, "", CodeMissingProperty, "schema '%s' has no db", name)
+	} else if s, ok := db.(string); !ok || strings.TrimSpace(s) == "" {
+		v.errorAt(schemaMap, "db", CodeInvalidValue, "db of schema '%s' must be a non-empty string", name)
+	} else if strings.ContainsAny(s, "/\\. \"$*<>:|?") {
+		v.errorAt(schemaMap, "db", CodeInvalidValue, "db name '%s' contains a character MongoDB does not allow", s)
+	}
+
+	collection, hasCollection := schemaMap["collection"]
+	if !hasCollection {
+		v.report(SeverityWarning, schemaMap, "", CodeMissingProperty, "schema '%s' has no collection; using '%s'", name, fallbackCollection)
+	} else if s, ok := collection.(string); !ok || strings.TrimSpace(s) == "" {
+		v.errorAt(schemaMap, "collection", CodeInvalidValue, "collection of schema '%s' must be a non-empty string", name)
+	} else if strings.Contains(s, "$") || strings.HasPrefix(s, "system.") {
+		v.errorAt(schemaMap, "collection", CodeInvalidValue, "collection name '%s' is reserved or contains '$'", s)
+	}
+
+	timestamps := false
+	if rawOptions, ok := schemaMap["options"]; ok {
+		options, ok := rawOptions.(map[string]interface{})
+		if !ok {
+			v.errorAt(schemaMap, "options", CodeInvalidValue, "options must be an object")
+		} else {
+			v.checkKeys(options, schemaOptions, "schema option")
+			if raw, ok := options["timestamps"]; ok {
+				timestamps, ok = raw.(bool)
+				if !ok {
+					v.errorAt(options, "timestamps", CodeInvalidValue, "options.timestamps must be a boolean")
+				}
+			}
+		}
+	}
+
+	fields, ok := schemaMap["fields"].(map[string]interface{})
+	switch {
+	case !ok && schemaMap["fields"] == nil:
+		v.errorAt(schemaMap, "", CodeMissingProperty, "schema '%s' has no fields", name)
+	case !ok:
+		v.errorAt(schemaMap, "fields", CodeInvalidValue, "fields of schema '%s' must be an object", name)
+	default:
+		v.checkFields(fields, "", true, timestamps)
+	}
+
+	return v.diags, v.refs
+}
+
+// checkFields validates the field names and definitions of a fields object.
+// Document-level names are also checked against `_id` and the timestamps.
+func (v *schemaValidator) checkFields(fields map[string]interface{}, prefix string, document, timestamps bool) {
+	for _, name := range orderedKeys(fields) {
+		path := prefix + name
+		switch {
+		case name == "":
+			v.errorAt(fields, name, CodeInvalidFieldName, "field names must not be empty")
+		case strings.HasPrefix(name, "$"):
+			v.errorAt(fields, name, CodeInvalidFieldName, "field name '%s' must not start with '$'", path)
+		case strings.Contains(name, "."):
+			v.errorAt(fields, name, CodeInvalidFieldName, "field name '%s' must not contain '.'", path)
+		case document && name == "_id":
+			v.errorAt(fields, name, CodeInvalidFieldName, "'_id' is added to every document and cannot be redefined")
+		case document && timestamps && contains(timestampFields, name):
+			v.errorAt(fields, name, CodeInvalidFieldName, "field '%s' clashes with the timestamp added by options.timestamps", name)
+		}
+		v.checkFieldValue(fields[name], fields, name, path)
+	}
+}
+
+// checkFieldValue validates a field definition or the `[field]` array shorthand
+// found under key in parent.
+func (v *schemaValidator) checkFieldValue(val interface{}, parent map[string]interface{}, key, path string) {
+	switch f := val.(type) {
+	case map[string]interface{}:
+		v.checkField(f, path)
+	case []interface{}:
+		if len(f) != 1 {
+			v.errorAt(parent, key, CodeInvalidDefinition, "array shorthand for '%s' must hold exactly one field definition, got %d", path, len(f))
+			return
+		}
+		v.checkFieldValue(f[0], parent, key, path+"[]")
+	default:
+		v.errorAt(parent, key, CodeInvalidDefinition, "'%s' must be a field definition such as fields.string(), got %s", path, describeValue(val))
+	}
+}
+
+// checkField validates the options of a single field definition.
+func (v *schemaValidator) checkField(field map[string]interface{}, path string) {
+	fieldType, _ := field["type"].(string)
+	props, ok := fieldProps[fieldType]
+	if !ok {
+		types := make([]string, 0, len(fieldProps))
+		for t := range fieldProps {
+			types = append(types, t)
+		}
+		v.errorAt(field, "", CodeUnknownFieldType, "unknown field type '%s' for '%s'%s", fieldType, path, didYouMean(fieldType, types))
+		return
+	}
+	v.checkKeys(field, append(append([]string{}, baseFieldProps...), props...), fieldType+" field option")
+
+	for _, flag := range []string{"required", "optional", "unique"} {
+		if raw, ok := field[flag]; ok {
+			if _, ok := raw.(bool); !ok {
+				v.errorAt(field, flag, CodeInvalidValue, "%s of '%s' must be a boolean", flag, path)
+			}
+		}
+	}
+	if field["required"] == true && field["optional"] == true {
+		v.errorAt(field, "optional", CodeConflictingOptions, "'%s' cannot be both required and optional", path)
+	}
+
+	v.checkRange(field, path, "minLength", "maxLength", true)
+	v.checkRange(field, path, "minItems", "maxItems", true)
+	v.checkRange(field, path, "min", "max", false)
+
+	if raw, ok := field["pattern"]; ok {
+		if _, ok := raw.(string); !ok {
+			v.errorAt(field, "pattern", CodeInvalidValue, "pattern of '%s' must be a string", path)
+		}
+	}
+	if raw, ok := field["ref"]; ok {
+		if s, ok := raw.(string); !ok || s == "" {
+			v.errorAt(field, "ref", CodeInvalidValue, "ref of '%s' must be a schema name", path)
+		} else {
+			v.addReference(field, path, s)
+		}
+	}
+
+	var enum []string
+	if raw, ok := field["enum"]; ok {
+		values, ok := raw.([]interface{})
+		if ok && len(values) == 0 {
+			ok = false
+		}
+		for _, value := range values {
+			s, isString := value.(string)
+			ok = ok && isString
+			enum = append(enum, s)
+		}
+		if !ok {
+			v.errorAt(field, "enum", CodeInvalidValue, "enum of '%s' must be a non-empty array of strings", path)
+			enum = nil
+		}
+	}
+
+	if def, ok := field["default"]; ok {
+		if !defaultMatches(fieldType, def) {
+			v.errorAt(field, "default", CodeInvalidValue, "default of %s field '%s' cannot be %s", fieldType, path, describeValue(def))
+		} else if s, isString := def.(string); isString && enum != nil && !contains(enum, s) {
+			v.errorAt(field, "default", CodeConflictingOptions, "default '%s' of '%s' is not one of its enum values", s, path)
+		}
+	}
+
+	switch fieldType {
+	case "object":
+		schema, ok := field["schema"].(map[string]interface{})
+		if !ok {
+			v.errorAt(field, "", CodeInvalidDefinition, "object field '%s' must have an object schema", path)
+			return
+		}
+		// A subdocument's own fields are named after it, so one mistake in a
+		// shared subdocument is reported once.
+		prefix := path + "."
+		if subDocument, ok := field[subDocumentKey].(string); ok {
+			prefix = subDocument + "."
+		}
+		v.checkFields(schema, prefix, false, false)
+	case "array":
+		v.checkFieldValue(field["items"], field, "items", path+"[]")
+	}
+}
+
+// checkRange checks that the lower and upper bound options of a field are
+// numbers (non-negative integers for lengths and counts) in the right order.
+func (v *schemaValidator) checkRange(field map[string]interface{}, path, lowerKey, upperKey string, count bool) {
+	bound := func(key string) (float64, bool) {
+		raw, ok := field[key]
+		if !ok {
+			return 0, false
+		}
+		n, ok := floatValue(raw)
+		if count {
+			i, isInt := intValue(raw)
+			ok = isInt && i >= 0
+		}
+		if !ok {
+			kind := "a number"
+			if count {
+				kind = "a non-negative integer"
+			}
+			v.errorAt(field, key, CodeInvalidValue, "%s of '%s' must be %s", key, path, kind)
+		}
+		return n, ok
+	}
+	lower, hasLower := bound(lowerKey)
+	upper, hasUpper := bound(upperKey)
+	if hasLower && hasUpper && lower > upper {
+		v.errorAt(field, lowerKey, CodeConflictingOptions, "%s of '%s' is greater than its %s", lowerKey, path, upperKey)
+	}
+}
+
+// defaultMatches reports whether def is a usable default for the field type.
+func defaultMatches(fieldType string, def interface{}) bool {
+	switch def.(type) {
+	case string:
+		return fieldType == "string" || fieldType == "date" || fieldType == "objectId"
+	case bool:
+		return fieldType == "boolean"
+	case int64, float64:
+		return fieldType == "number" || fieldType == "date"
+	}
+	return false
+}
+
+// checkKeys reports every property of obj that is not in allowed.
+func (v *schemaValidator) checkKeys(obj map[string]interface{}, allowed []string, what string) {
+	for _, key := range orderedKeys(obj) {
+		if !contains(allowed, key) {
+			v.errorAt(obj, key, CodeUnknownProperty, "unknown %s '%s'%s", what, key, didYouMean(key, allowed))
+		}
+	}
+}
+
+func (v *schemaValidator) errorAt(obj map[string]interface{}, key, code, format string, args ...interface{}) {
+	v.report(SeverityError, obj, key, code, format, args...)
+}
+
+// report records a diagnostic at key of obj, or at obj itself when the key
+// is empty or its position unknown.
+func (v *schemaValidator) report(severity Severity, obj map[string]interface{}, key, code, format string, args ...interface{}) {
+	d := *v.bundle.diagnosticAt(position(obj, key), severity, code, fmt.Sprintf(format, args...))
+	if v.seen[d] {
+		return
+	}
+	v.seen[d] = true
+	v.diags = append(v.diags, d)
+}
+
+// addReference records the ref of the field at path, once per source position.
+func (v *schemaValidator) addReference(field map[string]interface{}, path, target string) {
+	at := v.bundle.diagnosticAt(position(field, "ref"), SeverityError, CodeDanglingReference, "")
+	ref := Reference{Field: path, Target: target, File: at.File, Line: at.Line, Column: at.Column}
+	for _, existing := range v.refs {
+		if existing == ref {
+			return
+		}
+	}
+	v.refs = append(v.refs, ref)
+}
+
+// position returns where key of obj was written, or obj itself when the key
+// is empty or its position unknown.
+func position(obj map[string]interface{}, key string) file.Idx {
+	idx, _ := obj[posKey].(file.Idx)
+	if positions, ok := obj[keysKey].(map[string]file.Idx); ok && key != "" {
+		if keyIdx, ok := positions[key]; ok {
+			idx = keyIdx
+		}
+	}
+	return idx
+}
+
+// checkReferences reports refs that name no schema of the run, and warns
+// about refs into another database, which $lookup-based populate cannot follow.
+func checkReferences(schemas []Schema) Diagnostics {
+	byName := make(map[string]Schema, len(schemas))
+	names := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		byName[schema.Name] = schema
+		names = append(names, schema.Name)
+	}
+
+	var diags Diagnostics
+	for _, schema := range schemas {
+		for _, ref := range schema.References {
+			d := Diagnostic{File: ref.File, Line: ref.Line, Column: ref.Column}
+			target, ok := byName[ref.Target]
+			switch {
+			case !ok:
+				d.Severity, d.Code = SeverityError, CodeDanglingReference
+				d.Message = fmt.Sprintf("'%s.%s' refers to '%s', which is not a schema%s", schema.Name, ref.Field, ref.Target, didYouMean(ref.Target, names))
+			case target.DB != schema.DB:
+				d.Severity, d.Code = SeverityWarning, CodeCrossDatabaseRef
+				d.Message = fmt.Sprintf("'%s.%s' refers to '%s' in database '%s', which populate cannot follow from '%s'", schema.Name, ref.Field, ref.Target, target.DB, schema.DB)
+			default:
+				continue
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
+
+// checkDuplicates reports schemas that share a name, whose generated files
+// would overwrite each other, or a collection, whose validators would. Each
+// clash is reported at both definitions.
+func checkDuplicates(schemas []Schema) Diagnostics {
+	var diags Diagnostics
+	report := func(schema, other Schema, format string, args ...interface{}) {
+		d := Diagnostic{File: schema.File, Line: schema.Line, Column: schema.Column, Severity: SeverityError, Code: CodeDuplicateSchema}
+		d.Message = fmt.Sprintf(format, args...) + " at " + schemaLocation(other)
+		diags = append(diags, d)
+	}
+	for i, schema := range schemas {
+		for _, other := range schemas[:i] {
+			if schema.Name == other.Name {
+				report(schema, other, "schema name '%s' is also defined", schema.Name)
+				report(other, schema, "schema name '%s' is also defined", schema.Name)
+			}
+			if schema.DB == other.DB && schema.Collection == other.Collection {
+				report(schema, other, "collection '%s.%s' is also used by schema '%s'", schema.DB, schema.Collection, other.Name)
+				report(other, schema, "collection '%s.%s' is also used by schema '%s'", schema.DB, schema.Collection, schema.Name)
+			}
+		}
+	}
+	return diags
+}
+
+// schemaLocation formats where a schema is defined, for messages.
+func schemaLocation(schema Schema) string {
+	if schema.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d", schema.File, schema.Line, schema.Column)
+	}
+	return schema.File
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// describeValue names the kind of a converted value for messages.
+func describeValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case int64, float64:
+		s, _ := jsToString(v)
+		return s
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// didYouMean returns a "; did you mean 'x'?" hint naming the candidate closest
+// to name, or "" when none is close enough to be a likely typo.
+func didYouMean(name string, candidates []string) string {
+	best, bestDistance := "", len(name)/3+2
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted)
+	for _, candidate := range sorted {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean '%s'?", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
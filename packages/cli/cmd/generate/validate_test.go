@@ -0,0 +1,47 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDuplicatesReportsBothDefinitions(t *testing.T) {
+	schemas := []Schema{
+		{Name: "User", DB: "app", Collection: "users", File: "a.monkko.ts", Line: 3, Column: 3},
+		{Name: "User", DB: "app", Collection: "people", File: "b.monkko.ts", Line: 5, Column: 3},
+		{Name: "Member", DB: "app", Collection: "users", File: "b.monkko.ts", Line: 12, Column: 3},
+		{Name: "Admin", DB: "other", Collection: "users", File: "c.monkko.ts", Line: 1, Column: 1},
+	}
+	diags := checkDuplicates(schemas)
+	if len(diags) != 4 {
+		t.Fatalf("got %d diagnostic(s), want 4: %v", len(diags), diags)
+	}
+	for _, want := range []string{
+		"b.monkko.ts:5:3: error[duplicate-schema]: schema name 'User' is also defined at a.monkko.ts:3:3",
+		"a.monkko.ts:3:3: error[duplicate-schema]: schema name 'User' is also defined at b.monkko.ts:5:3",
+		"b.monkko.ts:12:3: error[duplicate-schema]: collection 'app.users' is also used by schema 'User' at a.monkko.ts:3:3",
+		"a.monkko.ts:3:3: error[duplicate-schema]: collection 'app.users' is also used by schema 'Member' at b.monkko.ts:12:3",
+	} {
+		found := false
+		for _, d := range diags {
+			found = found || d.String() == want
+		}
+		if !found {
+			t.Errorf("missing %q in:\n%v", want, diags)
+		}
+	}
+}
+
+func TestSameTargetClashIsNotBlamedOnTargets(t *testing.T) {
+	templates, diags := LoadTemplates("", false)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	ctx := &RenderContext{Templates: templates, Config: &Config{OutputDir: "out", Index: IndexFlat}}
+	_, diags = RenderOutputs([]Schema{sampleSchema, sampleSchema}, []TargetConfig{{Name: "zod", OutputDir: "out"}}, ctx, false)
+	for _, d := range diags {
+		if strings.Contains(d.Message, "both write this file") {
+			t.Errorf("unexpected target clash: %v", d)
+		}
+	}
+}
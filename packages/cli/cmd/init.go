@@ -65,26 +65,33 @@ func createConfigFile() error {
 
 func updateGitignore() error {
 	const outputDir = "types/monkko"
+	const cacheDir = ".monkko"
 	const gitignoreFile = ".gitignore"
 
 	// Check if .gitignore exists
 	var existingContent []string
+	ignored := make(map[string]bool)
 	if file, err := os.Open(gitignoreFile); err == nil {
 		defer file.Close()
 		scanner := bufio.NewScanner(file)
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
 			existingContent = append(existingContent, line)
+			ignored[strings.TrimSuffix(strings.TrimPrefix(line, "/"), "/")] = true
+		}
+	}
 
-			// Check if outputDir is already ignored
-			if line == outputDir || line == "/"+outputDir {
-				// Already exists, no need to add
-				return nil
-			}
+	// Add the output and cache directories unless already ignored
+	var missing []string
+	for _, entry := range []string{outputDir, cacheDir} {
+		if !ignored[entry] {
+			missing = append(missing, entry)
 		}
 	}
+	if len(missing) == 0 {
+		return nil
+	}
 
-	// Add outputDir to .gitignore
 	file, err := os.OpenFile(gitignoreFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
@@ -98,7 +105,7 @@ func updateGitignore() error {
 		}
 	}
 
-	// Add comment and outputDir
-	_, err = file.WriteString("# Monkko generated types\n" + outputDir + "\n")
+	// Add comment and the missing entries
+	_, err = file.WriteString("# Monkko generated types and parse cache\n" + strings.Join(missing, "\n") + "\n")
 	return err
 }
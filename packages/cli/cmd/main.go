@@ -9,8 +9,9 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "monkko",
-	Short: "High-performance developer toolkit for Monkko ODM",
+	Use:     "monkko",
+	Short:   "High-performance developer toolkit for Monkko ODM",
+	Version: generate.Version,
 	Long: `Monkko Kit is a fast CLI tool for generating TypeScript types from Monkko ODM schemas.
 	
 Built with Go for maximum speed and reliability.`,
@@ -26,5 +27,7 @@ func main() {
 func init() {
 	// Add commands
 	rootCmd.AddCommand(generate.Cmd)
+	rootCmd.AddCommand(generate.CleanCmd)
+	rootCmd.AddCommand(generate.LsFilesCmd)
 	rootCmd.AddCommand(initCmd)
 }
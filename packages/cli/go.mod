@@ -5,12 +5,12 @@ go 1.21
 require (
 	github.com/dop251/goja v0.0.0-20250531102226-cb187b08699c
 	github.com/evanw/esbuild v0.25.5
+	github.com/go-sourcemap/sourcemap v2.1.3+incompatible
 	github.com/spf13/cobra v1.8.0
 )
 
 require (
 	github.com/dlclark/regexp2 v1.11.4 // indirect
-	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
@@ -2,6 +2,7 @@
 
 const { execSync } = require('child_process');
 const os = require('os');
+const { version } = require('../package.json');
 
 function buildForCurrentPlatform() {
   const platform = os.platform();
@@ -34,7 +35,9 @@ function buildForCurrentPlatform() {
     GOARCH: arch === 'arm64' ? 'arm64' : 'amd64'
   };
   
-  execSync(`go build -o bin/${binaryName} ./cmd/`, { 
+  // The version keys the parse cache, so a new release never reuses old entries.
+  const ldflags = `-X github.com/monkko/kit/cmd/generate.Version=${version}`;
+  execSync(`go build -ldflags "${ldflags}" -o bin/${binaryName} ./cmd/`, { 
     stdio: 'inherit',
     env: env
   });
@@ -0,0 +1,13 @@
+import { BaseField, ArrayField, MonkkoField } from '../../types';
+
+export interface ArrayFieldProps extends BaseField {
+    minItems?: number;
+    maxItems?: number;
+}
+
+export function createArrayField<T extends MonkkoField>(
+  items: T,
+  opts?: ArrayFieldProps
+): ArrayField<T> {
+  return { ...opts, type: 'array', items };
+}
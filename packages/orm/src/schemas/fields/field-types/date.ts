@@ -1,7 +1,8 @@
 import { BaseField } from "../../types";
 
 export interface DateFieldProps extends BaseField {
-    default?: Date;
+    /** A fixed date, or "now" / a factory for the time of insertion. */
+    default?: Date | "now" | (() => Date);
 }
 
 export interface DateField extends DateFieldProps {
@@ -4,6 +4,7 @@ import { createBooleanField } from "./field-types/boolean";
 import { createObjectIdField } from "./field-types/objectId";
 import { createDateField } from "./field-types/date";
 import { createObjectField } from "./field-types/object";
+import { createArrayField } from "./field-types/array";
 
 
 export const fields = {
@@ -13,4 +14,5 @@ export const fields = {
     date: createDateField,
     objectId: createObjectIdField,
     object: createObjectField,
+    array: createArrayField,
 }
@@ -3,6 +3,7 @@ import { DateField } from "./fields/field-types/date";
 import { NumberField } from "./fields/field-types/number";
 import { ObjectIdField } from "./fields/field-types/objectId";
 import { StringField } from "./fields/field-types/string";
+import type { ArrayFieldProps } from "./fields/field-types/array";
 import type { ObjectId } from "mongodb";
 
 // Re-export individual field types and their props
@@ -11,8 +12,9 @@ export type { DateField, DateFieldProps } from "./fields/field-types/date";
 export type { NumberField, NumberFieldProps } from "./fields/field-types/number";
 export type { ObjectIdField, ObjectIdFieldProps } from "./fields/field-types/objectId";
 export type { StringField, StringFieldProps } from "./fields/field-types/string";
+export type { ArrayFieldProps } from "./fields/field-types/array";
 
-export type FieldType = 'string' | 'number' | 'boolean' | 'date' | 'objectId' | 'object';
+export type FieldType = 'string' | 'number' | 'boolean' | 'date' | 'objectId' | 'object' | 'array';
 
 export type BaseField = {
     required?: boolean;
@@ -28,7 +30,12 @@ export interface ObjectField<T extends Record<string, MonkkoField>> extends Obje
     type: 'object';
 }
 
-export type MonkkoField = StringField | NumberField | BooleanField | DateField | ObjectIdField | ObjectField<Record<string, MonkkoField>>;
+export interface ArrayField<T extends MonkkoField> extends ArrayFieldProps {
+    type: 'array';
+    items: T;
+}
+
+export type MonkkoField = StringField | NumberField | BooleanField | DateField | ObjectIdField | ObjectField<Record<string, MonkkoField>> | ArrayField<MonkkoField>;
 
 /**
  * Infers the actual TypeScript type (
@@ -42,5 +49,6 @@ export type InferMonkkoFieldType<F> =
   F extends DateField ? Date :
   F extends ObjectIdField ? ObjectId :
   F extends ObjectField<infer S> ? { [K in keyof S]: InferMonkkoFieldType<S[K]> } :
+  F extends ArrayField<infer U> ? InferMonkkoFieldType<U>[] :
   F extends [infer U] ? InferMonkkoFieldType<U>[] :
   never;
\ No newline at end of file